@@ -1,37 +1,111 @@
 package core
 
 import (
+	"path"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type AsynchronousTemporalQueue struct {
+// TemporalQueue 是以任意类型P作为时间戳的异步时间队列，时间戳的排序与距离由Timeline描述。
+type TemporalQueue[P any] struct {
 	channelMap     sync.Map
-	durationWindow time.Duration
-	curNTP         int64
-	sampleMode     bool
+	timeline       Timeline[P]
+	durationWindow atomic.Int64
+	samplerState   atomic.Int32
+	samplerMu      sync.Mutex
+	samplerDone    chan struct{}
 	sampleWeights  sync.Map
-	item_buffer    []map[string]any
-	out            *asynchronousTemporalQueueItem
+	out            *asynchronousTemporalQueueItem[P]
+	onChannelState atomic.Pointer[func(key string, from, to ChannelState)]
+	onSamplerState atomic.Pointer[func(from, to SamplerState)]
+	onEvent        atomic.Pointer[func(event LifecycleEvent)]
+	subsMu         sync.Mutex
+	subs           atomic.Pointer[[]*subscription]
+	membershipMu   sync.Mutex
+	groups         sync.Map
+	draining       sync.Map
+	output         P
+	hasOutput      bool
+	options        queueOptions
+	newestMu       sync.Mutex
+	newest         P
+	hasNewest      bool
+	evicted        atomic.Uint64
+	onEvict        atomic.Pointer[func(key string, value any, NTP P)]
+	sweepMu        sync.Mutex
+	sweepStop      chan struct{}
+	ready          chan struct{}
+	// consumeMu 使Pop、Head与PopN等消费者互斥，并发的消费者不会把同一帧拆开。加锁顺序为consumeMu、indexMu、通道项的锁。
+	consumeMu sync.Mutex
+	indexMu   sync.Mutex
+	index     headIndex[P]
+	scratch   []*asynchronousTemporalQueueItem[P]
+	maps      sync.Pool
+}
+
+// AsynchronousTemporalQueue 是以int64 Unix纳秒时间戳作为时间戳的异步时间队列。
+//
+// 除TemporalQueue的全部方法外，它还提供只对纳秒时间戳有意义的方法，如PushRaw与PushNTP64。
+type AsynchronousTemporalQueue struct {
+	*TemporalQueue[int64]
 }
 
 // NewAsynchronousTemporalQueue 创建一个新的异步时间队列实例。
 //
+// 参数 opts ...QueueOption: 可选的队列配置，如WithTTL。
+//
 // 返回值 *AsynchronousTemporalQueue: 返回一个初始化好的异步时间队列指针。
+func NewAsynchronousTemporalQueue(opts ...QueueOption) *AsynchronousTemporalQueue {
+	return &AsynchronousTemporalQueue{
+		TemporalQueue: NewTemporalQueue(UnixNanoTimeline(), opts...),
+	}
+}
+
+// NewTemporalQueue 创建一个以timeline描述时间戳的异步时间队列实例。
+//
+// 参数：
 //
-// 这个函数不接受任何参数。
-func NewAsynchronousTemporalQueue() *AsynchronousTemporalQueue {
+//	timeline Timeline[P]: 时间戳类型P的时间线，其Less与Distance不能为nil。
+//	opts ...QueueOption: 可选的队列配置，如WithTTL。
+func NewTemporalQueue[P any](timeline Timeline[P], opts ...QueueOption) *TemporalQueue[P] {
+	if timeline.Less == nil || timeline.Distance == nil {
+		panic("core: timeline requires both Less and Distance")
+	}
+	options := queueOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if a := options.autoCreate; a != nil {
+		for _, pattern := range a.allow {
+			if _, err := path.Match(pattern, ""); err != nil {
+				panic("core: malformed auto-create pattern " + pattern)
+			}
+		}
+	}
 	// 初始化异步时间队列，其中channelMap使用sync.Map来保证并发安全。
-	return &AsynchronousTemporalQueue{
+	return &TemporalQueue[P]{
 		channelMap: sync.Map{},
+		timeline:   timeline,
+		options:    options,
+		out:        newAsynchronousTemporalQueueItem(timeline, channelOptions{ordering: OrderingMonotonic}),
+		ready:      make(chan struct{}, 1),
+		index:      headIndex[P]{less: timeline.Less},
 	}
 }
 
-// taskSample 方法用于对队列中的数据进行采样。
-func (q *AsynchronousTemporalQueue) taskSample() {
-	for q.sampleMode { // 当 sampleMode 为真时，执行采样循环。
-		clear(q.item_buffer) // 清空 item_buffer，这是队列的内部缓冲区。
+// taskSample 方法用于对队列中的数据进行采样，直至采样器离开Sampling状态，退出时关闭done。
+//
+// 采样窗口的状态（curNTP与item_buffer）只由采样goroutine访问，因此都是局部变量。
+func (q *TemporalQueue[P]) taskSample(done chan<- struct{}) {
+	defer close(done)
+	var curNTP P
+	hasCurNTP := false
+	item_buffer := make([]map[string]any, 0)
+	for q.SamplerState() == SamplerSampling { // 当采样器处于Sampling状态时，执行采样循环。
+		clear(item_buffer) // 清空 item_buffer，这是采样窗口的缓冲区。
+		item_buffer = item_buffer[:0]
 
 		max_index := 0                      // 定义 max_index 用于跟踪最大权重的索引。
 		max_val := 0.0                      // 定义 max_val 用于跟踪最大权重值。
@@ -40,14 +114,15 @@ func (q *AsynchronousTemporalQueue) taskSample() {
 		for { // 开始一个无限循环，用于处理队列中的数据。
 			// 从队列中弹出一个元素，包括其值、NTP时间戳和成功标志。
 			values, ntp, ok := q.pop()
-			if q.curNTP == 0 {
-				q.curNTP = ntp
+			if ok && !hasCurNTP {
+				curNTP = ntp
+				hasCurNTP = true
 			}
 			if ok { // 如果弹出成功（ok 为真）。
-				// fmt.Println(ntp, q.curNTP, q.durationWindow)
-				difflib := time.Duration(ntp - q.curNTP)
-				// fmt.Println(difflib, q.durationWindow)
-				if difflib < q.durationWindow { // 如果当前 NTP 时间戳与 curNTP 的差值小于 durationWindow。
+				// fmt.Println(ntp, curNTP, q.durationWindow.Load())
+				difflib := q.timeline.Distance(curNTP, ntp)
+				// fmt.Println(difflib, q.durationWindow.Load())
+				if difflib < time.Duration(q.durationWindow.Load()) { // 如果当前 NTP 时间戳与 curNTP 的差值小于 durationWindow。
 					sumWeight := 0.0                 // 初始化权重和。
 					for key, value := range values { // 遍历 values 中的每个键值对。
 						if weight, ok := q.sampleWeights.Load(key); ok { // 如果键对应的权重存在。
@@ -55,35 +130,44 @@ func (q *AsynchronousTemporalQueue) taskSample() {
 						}
 						approxy_res[key] = value // 将值添加到近似结果映射中。
 					}
-					q.item_buffer = append(q.item_buffer, values) // 将当前的 values 添加到 item_buffer 中。
+					item_buffer = append(item_buffer, values) // 将当前的 values 添加到 item_buffer 中。
 
 					// 如果当前累加的权重大于或等于之前的最大权重，更新最大权重和索引。
 					if sumWeight >= max_val {
 						max_val = sumWeight
-						max_index = len(q.item_buffer) - 1
+						max_index = len(item_buffer) - 1
 					}
 				} else { // 如果 NTP 时间戳与 curNTP 的差值不小于 durationWindow。
-					q.curNTP = ntp // 更新 curNTP 为当前的 NTP 时间戳。
+					curNTP = ntp // 更新 curNTP 为当前的 NTP 时间戳。
 					// 将 item_buffer 中最大权重对应的元素复制到近似结果映射中。
 
 					// 如果 item_buffer 不为空，将近似结果和当前的 NTP 时间戳推送到输出队列。
-					if len(q.item_buffer) != 0 {
+					if len(item_buffer) != 0 {
 
-						for key, value := range q.item_buffer[max_index] {
+						for key, value := range item_buffer[max_index] {
 							approxy_res[key] = value
 						}
-						q.out.queue.Push(approxy_res, q.curNTP)
+						q.out.push(approxy_res, curNTP)
+						q.notify()
 					}
 					break // 退出循环，因为我们已经处理了所有需要的数据。
 				}
 			} else { // 如果弹出失败（ok 为假）。
+				if q.SamplerState() != SamplerSampling { // 采样已停止，丢弃未完成的采样窗口。
+					return
+				}
 				runtime.Gosched() // 让出当前 goroutine，以便其他 goroutine 可以运行。
 			}
 		}
 	}
 }
 
-func (q *AsynchronousTemporalQueue) StartSample(sampleRate int, sampleWeights sync.Map) {
+// (q *TemporalQueue[P]) StartSample 以sampleRate（每秒帧数）开始采样，sampleWeights为各通道键到float64权重的映射。
+//
+// 采样器由Raw进入Sampling状态并启动采样goroutine；若已在采样，则只更新采样率与新通道的权重。
+func (q *TemporalQueue[P]) StartSample(sampleRate int, sampleWeights *sync.Map) {
+	q.samplerMu.Lock()
+	defer q.samplerMu.Unlock()
 	sampleWeights.Range(func(key, value any) bool {
 		if _, ok := q.sampleWeights.Load(key); !ok {
 			if _, ok = q.channelMap.Load(key); ok {
@@ -93,225 +177,341 @@ func (q *AsynchronousTemporalQueue) StartSample(sampleRate int, sampleWeights sy
 		return true
 	})
 	intervalInMilliSeconds := 1000.0 / float64(sampleRate)
-	q.durationWindow = time.Duration(intervalInMilliSeconds) * time.Millisecond
-	// fmt.Println("sampleRate:", sampleRate, "intervalInSeconds:", intervalInMilliSeconds, "durationWindow:", q.durationWindow)
-	if q.sampleMode {
+	q.durationWindow.Store(int64(time.Duration(intervalInMilliSeconds) * time.Millisecond))
+	// fmt.Println("sampleRate:", sampleRate, "intervalInSeconds:", intervalInMilliSeconds, "durationWindow:", q.durationWindow.Load())
+	if !q.transitionSampler(SamplerRaw, SamplerSampling) {
 		return
 	}
-	q.item_buffer = make([]map[string]any, 0)
-	q.out = NewAsynchronousTemporalQueueItem()
-	q.curNTP = 0
-	q.sampleMode = true
-	go q.taskSample()
+	q.samplerDone = make(chan struct{})
+	go q.taskSample(q.samplerDone)
 }
 
-func (q *AsynchronousTemporalQueue) CloseSample() {
-	q.sampleMode = false
+// (q *TemporalQueue[P]) CloseSample 停止采样：采样器进入Stopping状态，待采样goroutine退出后回到Raw状态。
+//
+// 采样输出中尚未被弹出的帧会保留，在下次采样时仍可通过Pop读取。
+func (q *TemporalQueue[P]) CloseSample() {
+	q.samplerMu.Lock()
+	defer q.samplerMu.Unlock()
+	if !q.transitionSampler(SamplerSampling, SamplerStopping) {
+		return
+	}
+	<-q.samplerDone
+	q.samplerDone = nil
+	q.transitionSampler(SamplerStopping, SamplerRaw)
 }
 
-// (q *AsynchronousTemporalQueue) CreateChannel 根据给定的键（key）在异步时间队列（q）中创建一个新的通道。
+// (q *TemporalQueue[P]) CreateChannel 根据给定的键（key）在异步时间队列（q）中创建一个新的通道。
 //
-// 参数 key string: 用于唯一标识新通道的字符串键。
+// 参数：
+//
+//	key string: 用于唯一标识新通道的字符串键。
+//	opts ...ChannelOption: 可选的通道配置，如WithChannelTTL。键所属分组的配置（参见ChannelGroup.Configure）先于opts应用。
 //
-// 函数首先检查队列中是否已存在与给定键关联的通道。如果不存在（即ok为false），则创建一个新的AsynchronousTemporalQueueItem，并将其存储到队列的channelMap中，以键key作为索引。
-func (q *AsynchronousTemporalQueue) CreateChannel(key string) {
-	if _, ok := q.channelMap.Load(key); !ok {
-		q.channelMap.Store(key, NewAsynchronousTemporalQueueItem())
+// 函数首先检查队列中是否已存在与给定键关联的打开的通道。如果不存在（即ok为false），则创建一个新的AsynchronousTemporalQueueItem，并将其存储到队列的channelMap中，以键key作为索引。
+//
+// 若该键的通道已关闭但尚未清空，则创建新一代通道：新通道立即接收推入的任务，旧通道在后台继续出队，
+// 同一个键的任务总是先输出旧通道的、再输出新通道的。
+func (q *TemporalQueue[P]) CreateChannel(key string, opts ...ChannelOption) {
+	if v, ok := q.channelMap.Load(key); !ok || v.(*asynchronousTemporalQueueItem[P]).loadState() != ChannelOpen {
+		options := channelOptions{}
+		for _, opt := range q.groupOptions(key) {
+			opt(&options)
+		}
+		for _, opt := range opts {
+			opt(&options)
+		}
+		item := newAsynchronousTemporalQueueItem(q.timeline, options)
+		item.key = key
+		item.options = options
+		q.replaceChannel(key, item)
 	}
 }
 
-// (q *AsynchronousTemporalQueue) CloseChannel 关闭异步时间队列（q）中与给定键（key）关联的通道。
+// (q *TemporalQueue[P]) CloseChannel 关闭异步时间队列（q）中与给定键（key）关联的通道。
 //
 // 参数 key string: 要关闭的通道的字符串键。
 //
 // 函数首先从队列的channelMap中加载与键key对应的值（通道项）。若该键存在且加载成功（ok为true），执行以下操作：
-//  1. 将通道项的_close标志设置为true，表示该通道应被关闭。
-//  2. 启动一个新的goroutine，用于等待当前正在处理的所有任务完成，并最终删除已关闭的通道。此goroutine执行如下逻辑：
-//     a. 无限循环，直到满足退出条件。
-//     b. 使用_item._wg等待所有正在执行的任务完成。
-//     c. 检查通道项的queue是否为空。若为空，表示所有任务已完成，此时从队列的channelMap中删除键key，并退出goroutine。
-func (q *AsynchronousTemporalQueue) CloseChannel(key string) {
+//  1. 在持有通道项写锁的情况下将其状态由ChannelOpen转换为ChannelClosing，此后推入的任务都会被拒绝。
+//  2. 通道中已有的任务仍会照常被弹出；通道清空后，状态依次转换为ChannelDrained与ChannelRemoved，并从channelMap中删除。
+//     若关闭时通道已为空，则立即被移除。
+func (q *TemporalQueue[P]) CloseChannel(key string) {
 	if v, ok := q.channelMap.Load(key); ok {
-		item := v.(*asynchronousTemporalQueueItem)
-		item._close = true
-		go func() {
-			for {
-				item._wg.Wait()
-				if item.queue.Empty() {
-					q.channelMap.Delete(key)
-					return
-				}
-			}
-		}()
-
+		item := v.(*asynchronousTemporalQueueItem[P])
+		item.mu.Lock()
+		closed := q.transition(item, ChannelOpen, ChannelClosing)
+		item.mu.Unlock()
+		if closed {
+			q.refresh(item)
+		}
 	}
 }
 
-// (q *AsynchronousTemporalQueue) Push 向异步时间队列（q）中与给定键（key）关联的通道添加一个带有NTP时间戳的新任务（value）。
+// (q *TemporalQueue[P]) Push 向异步时间队列（q）中与给定键（key）关联的通道添加一个带有NTP时间戳的新任务（value）。
 //
 // 参数：
 //
 //	key string: 目标通道的字符串键。
 //	value any: 要添加到通道的任务数据。
-//	NTP int64: 任务关联的NTP时间戳（单位：纳秒）。
+//	NTP P: 任务关联的时间戳（AsynchronousTemporalQueue中为Unix纳秒时间戳）。
 //
 // 函数首先从队列的channelMap中加载与键key对应的值（通道项）。若该键存在且加载成功（ok为true），执行以下操作：
-// 1. 检查通道项的_close标志，确保通道未被关闭。若通道未关闭，继续执行。
-// 2. 增加通道项的_wg计数器，表示开始一个新任务。
-// 3. 将任务数据（value）及其NTP时间戳（NTP）推入通道项的queue中。
-// 4. 减少通道项的_wg计数器，表示新任务添加完毕。
+// 1. 锁定通道项，检查其状态，确保通道仍处于ChannelOpen状态。若通道未关闭，继续执行。
+// 2. 将任务数据（value）及其NTP时间戳（NTP）推入通道项的存储（store）中，并释放锁。
+// 3. 若新任务成为了通道的队首，则更新队首索引。
 //
-// 注意：若给定键对应的通道已关闭，此函数将不会向其添加任务。
-func (q *AsynchronousTemporalQueue) Push(key string, value any, NTP int64) {
-	if v, ok := q.channelMap.Load(key); ok {
-		item := v.(*asynchronousTemporalQueueItem)
-		if !item._close {
-			item._wg.Add(1)
-			item.queue.Push(value, NTP)
-			item._wg.Done()
+// 注意：若给定键对应的通道已关闭，此函数将不会向其添加任务。若通道不存在且队列以WithAutoCreate创建，则先自动创建通道。
+func (q *TemporalQueue[P]) Push(key string, value any, NTP P) {
+	if item, ok := q.loadChannel(key); ok {
+		q.pushItem(item, value, NTP, false)
+	}
+}
+
+// pushItem 将任务推入通道项（item），withHandle为true时返回任务的句柄；若通道已关闭或其存储不支持句柄，则不推入任务并返回false。
+func (q *TemporalQueue[P]) pushItem(item *asynchronousTemporalQueueItem[P], value any, NTP P, withHandle bool) (h Handle, ok bool) {
+	var head P
+	var hadHead bool
+	if withHandle {
+		item.mu.Lock()
+		if item.loadState() == ChannelOpen {
+			_, head, hadHead = item.store.head()
+			h, ok = item.store.pushHandle(value, NTP)
+			h.owner = item.id
+		}
+		item.mu.Unlock()
+	} else {
+		item.lockPush()
+		if item.loadState() == ChannelOpen {
+			_, head, hadHead = item.store.head()
+			item.store.push(value, NTP)
+			ok = true
 		}
+		item.unlockPush()
+	}
+	if !ok {
+		return h, false
 	}
+	item.recordPush(NTP)
+	if !item.pushed.Load() && item.pushed.CompareAndSwap(false, true) {
+		q.emit(EventChannelFirstPush, item.key, item.generation)
+	}
+	if !hadHead || q.timeline.Less(NTP, head) {
+		q.refresh(item)
+	}
+	q.observe(NTP)
+	q.notify()
+	return h, true
+}
+
+// (q *TemporalQueue[P]) Ready 返回一个通知通道：每当有新任务可供Pop读取时，通道中会出现一个信号。
+//
+// 通道的缓冲区为1，多次推入只会合并为一个信号，因此消费者收到信号后应持续调用Pop直到其返回false，再重新等待信号。
+// 信号只是提示，收到信号后Pop仍可能返回false（例如任务已被其他消费者取走）。
+func (q *TemporalQueue[P]) Ready() <-chan struct{} {
+	return q.ready
 }
 
-// (q *AsynchronousTemporalQueue) Pop 从异步时间队列（q）中弹出最早到期的任务（按NTP时间戳排序），并返回一个包含所有弹出任务的数据及其所属通道键的映射，以及当前系统时间对应的NTP时间戳。
+// notify 以非阻塞的方式向Ready通道发送信号。信号已在通道中时直接返回，以免并发的生产者争用通道的锁。
+func (q *TemporalQueue[P]) notify() {
+	if len(q.ready) > 0 {
+		return
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// (q *TemporalQueue[P]) pop 从异步时间队列（q）中弹出最早到期的任务（按时间戳排序），并返回一个包含所有弹出任务的数据及其所属通道键的映射，以及这些任务的时间戳。
 // 返回值：
 //
 //	values map[string]any: 包含弹出任务数据及其所属通道键的映射。键为通道键（string类型），值为任务数据（any类型）。
-//	NTP int64: 当前系统时间对应的NTP时间戳（单位：纳秒）。
+//	NTP P: 弹出任务的时间戳。
 //	ok bool: 若成功弹出至少一个任务，则返回true；否则返回false。
 //
 // 函数执行流程如下：
-//  1. 初始化结果映射（results）、待处理通道键列表（keys）及当前系统时间对应的NTP时间戳（curNTP）。
-//  2. 遍历队列（q）中的所有通道项（channelMap），查找最早到期的任务（按NTP时间戳排序）：
-//     a. 若通道项未关闭且非空，则获取其队列头任务的NTP时间戳。
-//     b. 根据当前系统时间与队列头任务NTP时间戳的关系，更新keys列表和curNTP。
-//  3. 对于keys列表中的每个通道键，再次检查其对应通道项是否符合条件（未关闭且非空），并尝试弹出任务：
-//     a. 增加通道项的_wg计数器，表示开始处理任务。
-//     b. 弹出任务数据并减少通道项的_wg计数器。
-//     c. 若弹出成功，将任务数据添加到结果映射（results）。
-//  4. 检查结果映射（results）是否为空。若为空，返回nil、0和false；否则返回结果映射、当前NTP时间戳和true。
-func (q *AsynchronousTemporalQueue) pop() (values map[string]any, NTP int64, ok bool) {
-	results := make(map[string]any)
-	keys := make([]string, 0)
-	curNTP := time.Now().UnixNano()
-
-	q.channelMap.Range(func(key, value any) bool {
-		item := value.(*asynchronousTemporalQueueItem)
-		if !item._close && !item.queue.Empty() {
-			_, NTP, ok := item.queue.Head()
-			if ok {
-				if curNTP == NTP {
-					keys = append(keys, key.(string))
-				}
-				if curNTP > NTP {
-					clear(keys)
-					keys = append(keys, key.(string))
-					curNTP = NTP
-				}
-			}
-		}
-		return true
-	})
-
-	for _, key := range keys {
-		if v, ok := q.channelMap.Load(key); ok {
-			item := v.(*asynchronousTemporalQueueItem)
-			if !item._close && !item.queue.Empty() {
-				item._wg.Add(1)
-				value, _, ok := item.queue.Pop()
-				item._wg.Done()
-				if ok {
-					results[key] = value
-				}
-			}
-		}
-	}
-
-	if len(results) == 0 {
-		return nil, 0, false
-	} else {
-		return results, curNTP, true
-	}
+//  1. 从队首索引中取出队首时间戳最早的通道，逐出其过期任务并校验索引中记录的队首，直至堆顶的通道通过校验。
+//  2. 若该时间戳晚于当前时刻（时间线的Now），返回false。
+//  3. 从队首索引中收集所有队首时间戳与之相同的通道，逐出其过期任务后，对每个通道：
+//     a. 锁定通道项，若其队首时间戳确实相同，弹出任务数据并添加到结果映射（results）。
+//     b. 依据新的队首更新队首索引。
+//  4. 检查结果映射（results）是否为空。若为空，返回nil、0和false；否则返回结果映射、时间戳和true。
+//
+// pop不等待并发的推入：与推入竞争时，pop只返回此刻队首索引中已有的任务，尚未完成的推入留待之后的弹出。
+func (q *TemporalQueue[P]) pop() (values map[string]any, NTP P, ok bool) {
+	return q.take(true)
 }
 
-func (q *AsynchronousTemporalQueue) Pop() (values map[string]any, NTP int64, ok bool) {
-	if q.sampleMode {
-		v, ntp, ok := q.out.queue.Pop()
+func (q *TemporalQueue[P]) Pop() (values map[string]any, NTP P, ok bool) {
+	if q.sampling() {
+		v, ntp, ok := q.out.pop()
 		if ok {
 			// println(ntp)
 			return v.(map[string]any), ntp, true
 		} else {
-			return nil, 0, false
+			var zero P
+			return nil, zero, false
 		}
 	}
 	return q.pop()
 }
 
-// (q *AsynchronousTemporalQueue) Head 获取异步时间队列（q）中与给定键（key）关联的通道的队首任务数据（按NTP时间戳排序），并返回一个包含所有队首任务数据及其所属通道键的映射，以及当前系统时间对应的NTP时间戳。
-// 参数：
-//
-//	key string: 目标通道的字符串键。
-//
+// (q *TemporalQueue[P]) head 获取异步时间队列（q）中最早到期的队首任务数据（按时间戳排序），并返回一个包含所有队首任务数据及其所属通道键的映射，以及这些任务的时间戳。
 // 返回值：
 //
 //	values map[string]any: 包含队首任务数据及其所属通道键的映射。键为通道键（string类型），值为队首任务数据（any类型）。
-//	NTP int64: 当前系统时间对应的NTP时间戳（单位：纳秒）。
+//	NTP P: 队首任务的时间戳。
 //	ok bool: 若成功获取至少一个队首任务，则返回true；否则返回false。
 //
-// 函数执行流程如下：
-//  1. 初始化结果映射（results）、待处理通道键列表（keys）及当前系统时间对应的NTP时间戳（curNTP）。
-//  2. 遍历队列（q）中的所有通道项（channelMap），查找最早到期的任务（按NTP时间戳排序）：
-//     a. 若通道项未关闭且非空，则获取其队列头任务的NTP时间戳。
-//     b. 根据当前系统时间与队列头任务NTP时间戳的关系，更新keys列表和curNTP。
-//  3. 对于keys列表中的每个通道键，再次检查其对应通道项是否符合条件（未关闭且非空），并尝试获取队首任务数据：
-//     a. 获取队首任务数据。
-//     b. 若获取成功，将任务数据添加到结果映射（results）。
-//  4. 检查结果映射（results）是否为空。若为空，返回nil、0和false；否则返回结果映射、当前NTP时间戳和true。
-func (q *AsynchronousTemporalQueue) head() (values map[string]any, NTP int64, ok bool) {
-	results := make(map[string]any)
-	keys := make([]string, 0)
-	curNTP := time.Now().UnixNano()
-
-	q.channelMap.Range(func(key, value any) bool {
-		item := value.(*asynchronousTemporalQueueItem)
-		if !item._close && !item.queue.Empty() {
-			_, NTP, ok := item.queue.Head()
-			if ok {
-				if curNTP == NTP {
-					keys = append(keys, key.(string))
+// 函数的执行流程与pop相同，只是不会弹出任务。
+func (q *TemporalQueue[P]) head() (values map[string]any, NTP P, ok bool) {
+	return q.take(false)
+}
+
+// take 是pop与head的共同实现，remove为true时弹出所选的任务。
+func (q *TemporalQueue[P]) take(remove bool) (values map[string]any, NTP P, ok bool) {
+	q.consumeMu.Lock()
+	defer q.consumeMu.Unlock()
+	results := q.acquire()
+	NTP, ok = q.takeInto(results, remove, nil)
+	if !ok {
+		q.maps.Put(results)
+		var zero P
+		return nil, zero, false
+	}
+	return results, NTP, true
+}
+
+// takeInto 将队首时间戳最早的一组任务写入results，remove为true时弹出这些任务。
+// at不为nil时只选取时间戳恰为*at的任务组：若队列中最早的时间戳不等于*at，则什么也不做。
+// 返回所选任务的时间戳，以及是否写入了至少一个任务。
+func (q *TemporalQueue[P]) takeInto(results map[string]any, remove bool, at *P) (NTP P, ok bool) {
+	q.indexMu.Lock()
+	NTP, ok, pending := q.settle()
+	before := len(results)
+	if ok && at != nil && (q.timeline.Less(NTP, *at) || q.timeline.Less(*at, NTP)) {
+		ok = false
+	}
+	if ok {
+		q.scratch = q.index.collect(NTP, q.scratch[:0])
+		for _, item := range q.scratch {
+			if item != q.scratch[0] {
+				if evicted, n := q.expire(item); n > 0 && len(evicted) > 0 {
+					pending = append(pending, eviction[P]{key: item.key, items: evicted})
 				}
-				if curNTP > NTP {
-					clear(keys)
-					keys = append(keys, key.(string))
-					curNTP = NTP
+			}
+			if remove {
+				item.mu.Lock()
+				if value, head, ok := item.store.head(); ok && !q.timeline.Less(NTP, head) && !q.timeline.Less(head, NTP) {
+					item.store.pop()
+					item.lastPopped, item.hasLastPopped = head, true
+					results[item.key] = value
 				}
+				item.mu.Unlock()
+				q.reindex(item)
+			} else if value, head, ok := item.head(); ok && !q.timeline.Less(NTP, head) && !q.timeline.Less(head, NTP) {
+				results[item.key] = value
+			} else {
+				q.reindex(item)
 			}
 		}
-		return true
-	})
+		clear(q.scratch)
+		if remove && len(results) > before {
+			q.advanceOutput(NTP)
+		}
+	}
+	q.indexMu.Unlock()
 
-	for _, key := range keys {
-		if v, ok := q.channelMap.Load(key); ok {
-			item := v.(*asynchronousTemporalQueueItem)
-			if !item._close && !item.queue.Empty() {
-				value, _, ok := item.queue.Head()
-				if ok {
-					results[key] = value
-				}
-			}
+	for _, e := range pending {
+		q.report(e.key, e.items)
+	}
+	return NTP, len(results) > before
+}
+
+// earliest 返回队列中最早的队首时间戳；若队列为空或该时间戳晚于当前时刻（时间线的Now），返回false。
+func (q *TemporalQueue[P]) earliest() (NTP P, ok bool) {
+	q.indexMu.Lock()
+	NTP, ok, pending := q.settle()
+	q.indexMu.Unlock()
+
+	for _, e := range pending {
+		q.report(e.key, e.items)
+	}
+	return NTP, ok
+}
+
+// settle 逐出队首索引堆顶通道的过期任务并校验索引中记录的队首，直至堆顶的通道通过校验，返回其队首时间戳。
+// 若队列为空或该时间戳晚于当前时刻（时间线的Now），返回false。被逐出的任务记录在pending中，
+// 调用方须在释放indexMu后通过report上报。调用方须持有indexMu。
+func (q *TemporalQueue[P]) settle() (NTP P, ok bool, pending []eviction[P]) {
+	for {
+		top, ok := q.index.min()
+		if !ok {
+			break
+		}
+		if evicted, n := q.expire(top); n > 0 && len(evicted) > 0 {
+			pending = append(pending, eviction[P]{key: top.key, items: evicted})
 		}
+		NTP, ok = q.reindex(top)
+		if ok && top.indexPos == 0 {
+			break
+		}
+	}
+	if q.index.len() == 0 || q.timeline.Now != nil && q.timeline.Less(q.timeline.Now(), NTP) {
+		return NTP, false, pending
 	}
+	return NTP, true, pending
+}
 
-	if len(results) == 0 {
-		return nil, 0, false
-	} else {
-		return results, curNTP, true
+// (q *TemporalQueue[P]) Release 将Pop返回的结果映射（values）归还给队列，供之后的Pop复用，从而避免每次弹出都分配新的映射。
+//
+// 归还后调用方不得再访问values。Head返回的映射在采样模式下仍被队列引用，不能归还。
+func (q *TemporalQueue[P]) Release(values map[string]any) {
+	if values == nil {
+		return
 	}
+	clear(values)
+	q.maps.Put(values)
 }
 
-func (q *AsynchronousTemporalQueue) Head() (values map[string]any, NTP int64, ok bool) {
-	if q.sampleMode {
-		v, ntp, ok := q.out.queue.Head()
+// acquire 从映射池中取出一个空的结果映射，池为空时新建一个。
+func (q *TemporalQueue[P]) acquire() map[string]any {
+	if v := q.maps.Get(); v != nil {
+		return v.(map[string]any)
+	}
+	return make(map[string]any)
+}
+
+// refresh 依据通道项（item）当前的队首更新队首索引。
+func (q *TemporalQueue[P]) refresh(item *asynchronousTemporalQueueItem[P]) {
+	q.indexMu.Lock()
+	defer q.indexMu.Unlock()
+	q.reindex(item)
+}
+
+// reindex 是refresh的无锁版本，调用方须持有indexMu。返回通道项当前的队首时间戳；
+// 若通道项为空、已暂停或其前代通道尚未移除，则将其移出队首索引并返回false，已关闭且为空的通道项随之被移除。
+//
+// 前代通道尚未移除的通道项即使已关闭且为空也不会被移除：否则channelMap中的键会被删除，
+// 此后创建的通道不再链接到仍在出队的前代通道。前代通道被移除时会重新调用reindex移除该通道项。
+func (q *TemporalQueue[P]) reindex(item *asynchronousTemporalQueueItem[P]) (NTP P, ok bool) {
+	_, NTP, ok = item.head()
+	gated := item.gated()
+	if ok && !item.paused.Load() && !gated {
+		q.index.update(item, NTP)
+		return NTP, true
+	}
+	q.index.remove(item)
+	if !ok && !gated && item.loadState() == ChannelClosing {
+		q.retire(item)
+	}
+	var zero P
+	return zero, false
+}
+
+func (q *TemporalQueue[P]) Head() (values map[string]any, NTP P, ok bool) {
+	if q.sampling() {
+		v, ntp, ok := q.out.head()
 		if ok {
 			return v.(map[string]any), ntp, true
 		} else {
@@ -321,33 +521,101 @@ func (q *AsynchronousTemporalQueue) Head() (values map[string]any, NTP int64, ok
 	return q.head()
 }
 
-func (q *AsynchronousTemporalQueue) Empty() bool {
-	if q.sampleMode {
-		return q.out.queue.Empty()
+// (q *TemporalQueue[P]) Empty 返回Pop当前是否没有可读取的数据：原始模式下表示所有通道都为空，
+// 采样模式下表示采样输出中没有帧。通道中的任务数见Len与ChannelLen。
+func (q *TemporalQueue[P]) Empty() bool {
+	if q.sampling() {
+		return q.out.len() == 0
 	} else {
-		flag := true
-		q.channelMap.Range(func(key, value any) bool {
-			item := value.(*asynchronousTemporalQueueItem)
-			if !item._close && !item.queue.Empty() {
-				flag = false
-				return true
-			}
-			return true
-		})
-		return flag
+		q.indexMu.Lock()
+		defer q.indexMu.Unlock()
+		return q.index.len() == 0
+	}
+}
+
+type asynchronousTemporalQueueItem[P any] struct {
+	key    string
+	mu     sync.RWMutex
+	store  channelStore[P]
+	state  atomic.Int32
+	pushed atomic.Bool
+	paused atomic.Bool
+	// id是全局唯一的通道项编号，generation是同一个键的第几代通道；pred与successor分别指向前代与下一代通道。
+	id         uint32
+	generation uint64
+	pred       atomic.Pointer[asynchronousTemporalQueueItem[P]]
+	successor  atomic.Pointer[asynchronousTemporalQueueItem[P]]
+	unwrapper  atomic.Pointer[TimestampUnwrapper]
+	options    channelOptions
+	evicted    atomic.Uint64
+	indexPos   int
+	indexNTP   P
+
+	// lastPushed由lastMu保护，以免与并发推入的存储争用通道项的锁；lastPopped只在持有mu写锁时弹出任务时更新。
+	lastMu        sync.Mutex
+	lastPushed    P
+	hasLastPushed bool
+	lastPopped    P
+	hasLastPopped bool
+}
+
+func NewAsynchronousTemporalQueueItem() *asynchronousTemporalQueueItem[int64] {
+	return newAsynchronousTemporalQueueItem(UnixNanoTimeline(), channelOptions{})
+}
+
+func newAsynchronousTemporalQueueItem[P any](timeline Timeline[P], options channelOptions) *asynchronousTemporalQueueItem[P] {
+	return &asynchronousTemporalQueueItem[P]{
+		store:    newChannelStore(timeline.Less, options),
+		id:       channelIDs.Add(1),
+		indexPos: -1,
 	}
 }
 
-type asynchronousTemporalQueueItem struct {
-	queue  *PriorityQueue[any, int64]
-	_close bool
-	_wg    *sync.WaitGroup
+// push、pop、head与len是通道项存储的并发安全版本。
+func (item *asynchronousTemporalQueueItem[P]) push(value any, NTP P) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	item.store.push(value, NTP)
+}
+
+func (item *asynchronousTemporalQueueItem[P]) pop() (value any, NTP P, ok bool) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.store.pop()
+}
+
+func (item *asynchronousTemporalQueueItem[P]) head() (value any, NTP P, ok bool) {
+	item.mu.RLock()
+	defer item.mu.RUnlock()
+	return item.store.head()
+}
+
+// recordPush 记录通道项最近一次推入任务的时间戳。
+func (item *asynchronousTemporalQueueItem[P]) recordPush(NTP P) {
+	item.lastMu.Lock()
+	item.lastPushed, item.hasLastPushed = NTP, true
+	item.lastMu.Unlock()
+}
+
+// lockPush与unlockPush为推入任务加锁与解锁：存储支持并发推入时只需读锁。
+func (item *asynchronousTemporalQueueItem[P]) lockPush() {
+	if item.store.concurrent() {
+		item.mu.RLock()
+	} else {
+		item.mu.Lock()
+	}
 }
 
-func NewAsynchronousTemporalQueueItem() *asynchronousTemporalQueueItem {
-	return &asynchronousTemporalQueueItem{
-		queue:  NewMinPriorityQueue[any, int64](),
-		_close: false,
-		_wg:    &sync.WaitGroup{},
+func (item *asynchronousTemporalQueueItem[P]) unlockPush() {
+	if item.store.concurrent() {
+		item.mu.RUnlock()
+	} else {
+		item.mu.Unlock()
 	}
 }
+
+func (item *asynchronousTemporalQueueItem[P]) len() int {
+	item.mu.RLock()
+	defer item.mu.RUnlock()
+	return item.store.len()
+}
@@ -0,0 +1,84 @@
+package core
+
+import "path"
+
+// autoCreate 是WithAutoCreate等选项设置的自动创建通道配置。
+type autoCreate struct {
+	template []ChannelOption
+	factory  func(key string) []ChannelOption
+	allow    []string
+	// hasAllow 表示设置了WithAutoCreateAllowlist，此时空的allow拒绝所有键
+	hasAllow bool
+}
+
+// WithAutoCreate 使Push等推入函数在通道不存在时自动创建通道，新通道应用template中的配置，适用于运行时才发现的数据源。
+//
+// 自动创建只发生在键不存在（包括已被移除）时；已关闭但尚未清空的通道不会被重新创建，推入的任务照常被拒绝。
+// 可通过WithAutoCreateFunc按键选择配置，通过WithAutoCreateAllowlist限制允许自动创建的键。
+func WithAutoCreate(template ...ChannelOption) QueueOption {
+	return func(o *queueOptions) {
+		if o.autoCreate == nil {
+			o.autoCreate = &autoCreate{}
+		}
+		o.autoCreate.template = template
+	}
+}
+
+// WithAutoCreateFunc 与WithAutoCreate相同，但由factory按通道键返回新通道的配置，例如按键的前缀或path.Match模式选择不同的过期时间。
+// factory返回的配置在WithAutoCreate的模板之后应用。
+func WithAutoCreateFunc(factory func(key string) []ChannelOption) QueueOption {
+	return func(o *queueOptions) {
+		if o.autoCreate == nil {
+			o.autoCreate = &autoCreate{}
+		}
+		o.autoCreate.factory = factory
+	}
+}
+
+// WithAutoCreateAllowlist 只允许自动创建键与patterns中某个模式匹配的通道，模式语法同path.Match（如"logs/*"）。
+// 不匹配的键不会被自动创建，推入的任务与未开启自动创建时一样被丢弃，避免拼写错误的键悄悄创建出新通道。
+// 模式格式错误时NewTemporalQueue会panic。不带模式调用时不允许自动创建任何通道。
+//
+// 单独使用时以空模板开启自动创建。
+func WithAutoCreateAllowlist(patterns ...string) QueueOption {
+	return func(o *queueOptions) {
+		if o.autoCreate == nil {
+			o.autoCreate = &autoCreate{}
+		}
+		o.autoCreate.allow = patterns
+		o.autoCreate.hasAllow = true
+	}
+}
+
+// allowed 返回是否允许自动创建键为key的通道。
+func (a *autoCreate) allowed(key string) bool {
+	if !a.hasAllow {
+		return true
+	}
+	for _, pattern := range a.allow {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// loadChannel 返回与给定键（key）关联的通道项。若通道不存在且队列开启了自动创建，则先按自动创建配置创建通道。
+func (q *TemporalQueue[P]) loadChannel(key string) (*asynchronousTemporalQueueItem[P], bool) {
+	if v, ok := q.channelMap.Load(key); ok {
+		return v.(*asynchronousTemporalQueueItem[P]), true
+	}
+	a := q.options.autoCreate
+	if a == nil || !a.allowed(key) {
+		return nil, false
+	}
+	opts := a.template
+	if a.factory != nil {
+		opts = append(opts[:len(opts):len(opts)], a.factory(key)...)
+	}
+	q.CreateChannel(key, opts...)
+	if v, ok := q.channelMap.Load(key); ok {
+		return v.(*asynchronousTemporalQueueItem[P]), true
+	}
+	return nil, false
+}
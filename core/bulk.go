@@ -0,0 +1,284 @@
+package core
+
+import "sort"
+
+// Frame 是一次对齐弹出的结果，包含同一时间戳下各通道的任务数据。
+type Frame[P any] struct {
+	// Values 的键为通道键，值为该通道在此时间戳下的任务数据。
+	Values map[string]any
+	// NTP 为这些任务共同的时间戳。
+	NTP P
+	// Keys 是Values中的通道键，按通道优先级从高到低排列，优先级相同时按通道键排列，参见WithChannelPriority。
+	Keys []string
+}
+
+// Item 是一个带有时间戳的任务，用于批量推入。
+type Item[P any] struct {
+	Value any
+	NTP   P
+}
+
+// (q *TemporalQueue[P]) PushBatch 向与给定键（key）关联的通道批量添加任务（items）。
+//
+// 整批任务只加锁一次，并且只唤醒一次等待Ready的消费者。与Push一样，若通道不存在（且未开启自动创建）或已关闭，此函数将不会添加任务。
+func (q *TemporalQueue[P]) PushBatch(key string, items []Item[P]) {
+	if newest, ok := q.pushBatch(key, items); ok {
+		q.observe(newest)
+		q.notify()
+	}
+}
+
+// (q *TemporalQueue[P]) PushFrame 向多个通道各添加一个任务，frame的键为通道键。
+//
+// 每个通道只加锁一次，所有通道推入完毕后只唤醒一次消费者。不存在或已关闭的通道会被跳过。
+func (q *TemporalQueue[P]) PushFrame(frame map[string]Item[P]) {
+	var newest P
+	pushed := false
+	for key, item := range frame {
+		if _, ok := q.pushBatch(key, []Item[P]{item}); ok {
+			if !pushed || q.timeline.Less(newest, item.NTP) {
+				newest = item.NTP
+			}
+			pushed = true
+		}
+	}
+	if pushed {
+		q.observe(newest)
+		q.notify()
+	}
+}
+
+// pushBatch 在一次加锁内将items推入与key关联的通道，返回其中最新的时间戳以及是否推入了任务。
+func (q *TemporalQueue[P]) pushBatch(key string, items []Item[P]) (newest P, ok bool) {
+	if len(items) == 0 {
+		return newest, false
+	}
+	item, ok := q.loadChannel(key)
+	if !ok {
+		return newest, false
+	}
+
+	item.lockPush()
+	if item.loadState() != ChannelOpen {
+		item.unlockPush()
+		return newest, false
+	}
+	_, head, hadHead := item.store.head()
+	newest, earliest := items[0].NTP, items[0].NTP
+	for _, it := range items {
+		item.store.push(it.Value, it.NTP)
+		if q.timeline.Less(newest, it.NTP) {
+			newest = it.NTP
+		}
+		if q.timeline.Less(it.NTP, earliest) {
+			earliest = it.NTP
+		}
+	}
+	item.unlockPush()
+	item.recordPush(items[len(items)-1].NTP)
+	if !item.pushed.Load() && item.pushed.CompareAndSwap(false, true) {
+		q.emit(EventChannelFirstPush, item.key, item.generation)
+	}
+	if !hadHead || q.timeline.Less(earliest, head) {
+		q.refresh(item)
+	}
+	return newest, true
+}
+
+// (q *TemporalQueue[P]) PopUntil 按时间顺序弹出所有时间戳不晚于NTP的任务，并按与Pop相同的方式对齐为帧。
+//
+// 与Pop不同，PopUntil以NTP为上界，不再受时间线Now的限制。
+func (q *TemporalQueue[P]) PopUntil(NTP P) []Frame[P] {
+	return q.popFrames(-1, func(head P) bool {
+		return !q.timeline.Less(NTP, head)
+	})
+}
+
+// (q *TemporalQueue[P]) PopN 按时间顺序弹出至多n帧。与Pop一样，晚于时间线Now的任务不会被弹出。
+func (q *TemporalQueue[P]) PopN(n int) []Frame[P] {
+	if n <= 0 {
+		return nil
+	}
+	if q.timeline.Now == nil {
+		return q.popFrames(n, nil)
+	}
+	now := q.timeline.Now()
+	return q.popFrames(n, func(head P) bool {
+		return !q.timeline.Less(now, head)
+	})
+}
+
+// (q *TemporalQueue[P]) Drain 按时间顺序弹出队列中的全部任务，包括时间戳晚于时间线Now的任务。
+func (q *TemporalQueue[P]) Drain() []Frame[P] {
+	return q.popFrames(-1, nil)
+}
+
+// popFrames 按时间顺序弹出至多n帧（n为负时不限帧数），只弹出队首时间戳满足accept的任务（accept为nil时不限制）。
+//
+// 函数执行流程如下：
+//  1. 遍历一次channelMap与draining集合，收集所有未暂停的通道项（包括仍在出队的已关闭通道及其旧一代通道），逐出其中的过期任务，
+//     并按通道键与代排序以保证加锁顺序一致。
+//  2. 依次锁定所有通道项的队列，在整个弹出过程中只加锁一次。
+//  3. 以各通道队首时间戳建立最小堆，反复取出时间戳最早的一组通道，弹出其队首任务组成一帧，并将其新的队首放回堆中。
+//     等待前代通道的新一代通道在前代清空后的下一帧才放入堆中，使同一个键的任务先旧后新、且不会出现在同一帧中。
+//  4. 释放所有通道项的锁，并依据各通道新的队首更新队首索引。
+func (q *TemporalQueue[P]) popFrames(n int, accept func(P) bool) []Frame[P] {
+	if q.sampling() {
+		return q.popSampledFrames(n, accept)
+	}
+	q.consumeMu.Lock()
+	defer q.consumeMu.Unlock()
+
+	type channel struct {
+		item *asynchronousTemporalQueueItem[P]
+		// next 是channels中等待本通道清空的下一代通道的下标，没有时为-1。
+		next int
+		// waiting 表示本通道在等待channels中的前代通道清空。
+		waiting bool
+	}
+	channels := make([]channel, 0)
+	q.rangeItems(func(item *asynchronousTemporalQueueItem[P]) bool {
+		if evicted, n := q.expire(item); n > 0 {
+			q.report(item.key, evicted)
+		}
+		if !item.paused.Load() {
+			channels = append(channels, channel{item: item, next: -1})
+		}
+		return true
+	})
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].item.key != channels[j].item.key {
+			return channels[i].item.key < channels[j].item.key
+		}
+		return channels[i].item.generation < channels[j].item.generation
+	})
+	// 按顺序决定每个等待前代的通道是否参与本次弹出：前代通道已在本轮排除（如已暂停）时，
+	// 本通道也被排除，因此不会链接到被排除的通道。
+	for i := range channels {
+		if !channels[i].item.gated() {
+			continue
+		}
+		if i > 0 && channels[i-1].item != nil && channels[i].item.pred.Load() == channels[i-1].item {
+			channels[i-1].next = i
+			channels[i].waiting = true
+		} else {
+			channels[i].item = nil
+		}
+	}
+
+	heads := NewPriorityQueueFunc[int](q.timeline.Less)
+	// 队首时间戳相同的通道按优先级从高到低弹出；channels已按通道键排序，优先级相同时下标小者在前。
+	heads.SetTieBreaker(func(i, j int) bool {
+		if pi, pj := channels[i].item.options.priority, channels[j].item.options.priority; pi != pj {
+			return pi > pj
+		}
+		return i < j
+	})
+	for i, c := range channels {
+		if c.item == nil {
+			continue
+		}
+		c.item.mu.Lock()
+		if c.waiting && !channels[i-1].waiting && channels[i-1].item.store.len() == 0 {
+			// 前代通道已清空且不再等待更早的通道，只是尚未被移除
+			channels[i].waiting = false
+		}
+		if _, NTP, ok := c.item.store.head(); ok && !channels[i].waiting {
+			heads.push(i, NTP)
+		}
+	}
+
+	frames := make([]Frame[P], 0)
+	activate := make([]int, 0)
+	for n < 0 || len(frames) < n {
+		for _, i := range activate {
+			// 前代通道已在上一帧清空；已关闭且为空的中间一代通道不输出任务，直接激活它的下一代
+			for ; i >= 0; i = channels[i].next {
+				channels[i].waiting = false
+				if _, next, ok := channels[i].item.store.head(); ok {
+					heads.push(i, next)
+					break
+				}
+			}
+		}
+		activate = activate[:0]
+		_, NTP, ok := heads.head()
+		if !ok || accept != nil && !accept(NTP) {
+			break
+		}
+		frame := Frame[P]{Values: make(map[string]any), NTP: NTP}
+		for {
+			i, head, ok := heads.head()
+			if !ok || q.timeline.Less(NTP, head) {
+				break
+			}
+			heads.pop()
+			c := channels[i]
+			value, _, _ := c.item.store.pop()
+			c.item.lastPopped, c.item.hasLastPopped = head, true
+			frame.Values[c.item.key] = value
+			frame.Keys = append(frame.Keys, c.item.key)
+			if _, next, ok := c.item.store.head(); ok {
+				heads.push(i, next)
+			} else if c.next >= 0 {
+				activate = append(activate, c.next)
+			}
+		}
+		frames = append(frames, frame)
+	}
+
+	for _, c := range channels {
+		if c.item != nil {
+			c.item.mu.Unlock()
+		}
+	}
+	// 全部解锁后再更新队首索引：refresh需要持有indexMu，而take先持有indexMu再锁定通道项，不能在持有通道锁时获取indexMu。
+	for _, c := range channels {
+		if c.item != nil {
+			q.refresh(c.item)
+		}
+	}
+	if len(frames) > 0 {
+		q.indexMu.Lock()
+		q.advanceOutput(frames[len(frames)-1].NTP)
+		q.indexMu.Unlock()
+	}
+	return frames
+}
+
+// popSampledFrames 是popFrames在采样模式下的实现，直接从采样输出队列中弹出帧。
+func (q *TemporalQueue[P]) popSampledFrames(n int, accept func(P) bool) []Frame[P] {
+	frames := make([]Frame[P], 0)
+	q.out.mu.Lock()
+	defer q.out.mu.Unlock()
+	for n < 0 || len(frames) < n {
+		_, NTP, ok := q.out.store.head()
+		if !ok || accept != nil && !accept(NTP) {
+			break
+		}
+		v, _, _ := q.out.store.pop()
+		values := v.(map[string]any)
+		frames = append(frames, Frame[P]{Values: values, NTP: NTP, Keys: q.OrderedKeys(values)})
+	}
+	return frames
+}
+
+// (q *TemporalQueue[P]) OrderedKeys 返回Pop等函数返回的映射（values）中的通道键，按通道优先级从高到低排列，
+// 优先级相同时按通道键排列，参见WithChannelPriority。已不存在的通道按优先级0处理。
+func (q *TemporalQueue[P]) OrderedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	priorities := make(map[string]int, len(values))
+	for key := range values {
+		keys = append(keys, key)
+		if v, ok := q.channelMap.Load(key); ok {
+			priorities[key] = v.(*asynchronousTemporalQueueItem[P]).options.priority
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if pi, pj := priorities[keys[i]], priorities[keys[j]]; pi != pj {
+			return pi > pj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,267 @@
+package core
+
+// ChannelOrdering 描述通道中任务时间戳的有序程度，决定通道内部使用的存储方式。
+type ChannelOrdering int
+
+const (
+	// OrderingAuto 自动检测：通道以堆存储任务，连续推入足够多按时间顺序到达的任务后切换为环形缓冲区。
+	OrderingAuto ChannelOrdering = iota
+	// OrderingMonotonic 声明通道的时间戳单调不减：通道从一开始就使用环形缓冲区。
+	OrderingMonotonic
+	// OrderingHeap 始终以堆存储任务，适用于时间戳经常乱序的通道。
+	OrderingHeap
+)
+
+// ChannelStorage 选择通道存储任务所用的数据结构。
+type ChannelStorage int
+
+const (
+	// StorageHybrid 是默认存储：时间戳单调时使用环形缓冲区，出现乱序任务时回退到堆，参见WithOrdering。
+	// 每次推入都独占通道的锁。
+	StorageHybrid ChannelStorage = iota
+	// StorageSkipList 使用并发跳表存储任务：推入的代价为O(log n)，但多个生产者可以同时向同一通道推入任务而不互相阻塞，
+	// 适用于被大量goroutine并发推入的热点通道。该存储忽略WithOrdering，且不支持PushHandle。
+	StorageSkipList
+)
+
+// monotonicStreak 是OrderingAuto与OrderingMonotonic模式下由堆切换回环形缓冲区所需的最少连续有序推入次数。
+const monotonicStreak = 64
+
+// WithOrdering 设置通道的时间戳有序程度，默认为OrderingAuto。
+//
+// 在OrderingAuto与OrderingMonotonic模式下，时间戳单调的任务以O(1)的代价进出环形缓冲区；
+// 一旦出现乱序任务，通道会回退到堆存储以保证正确性，并在重新检测到连续有序的推入后切换回环形缓冲区。
+func WithOrdering(ordering ChannelOrdering) ChannelOption {
+	return func(o *channelOptions) {
+		o.ordering = ordering
+	}
+}
+
+// WithStorage 设置通道存储任务所用的数据结构，默认为StorageHybrid。
+func WithStorage(storage ChannelStorage) ChannelOption {
+	return func(o *channelOptions) {
+		o.storage = storage
+	}
+}
+
+// channelStore 是通道内任务的存储，弹出顺序为时间戳从早到晚，时间戳相同的任务按推入顺序弹出。
+//
+// 调用方须持有通道项的写锁；若concurrent返回true，push、head与len只需持有读锁，且push可并发调用。
+// 不支持句柄的存储在pushHandle中返回false，不区分两种存储方式的存储在migrations中返回0。
+type channelStore[P any] interface {
+	push(value any, NTP P)
+	pop() (value any, NTP P, ok bool)
+	head() (value any, NTP P, ok bool)
+	len() int
+	concurrent() bool
+	migrations() int
+	pushHandle(value any, NTP P) (Handle, bool)
+	update(h Handle, NTP P) bool
+	remove(h Handle) (value any, NTP P, ok bool)
+}
+
+// newChannelStore 按通道配置创建存储。
+func newChannelStore[P any](less func(a, b P) bool, options channelOptions) channelStore[P] {
+	if options.storage == StorageSkipList {
+		return newSkipList(less)
+	}
+	return newHybridStore(less, options.ordering)
+}
+
+// hybridStore 是通道内任务的存储：时间戳单调时使用环形缓冲区，出现乱序任务时回退到堆。
+// 两种存储都按先进先出的顺序弹出时间戳相同的任务。
+//
+// hybridStore不是并发安全的，调用方须持有通道项的锁。
+type hybridStore[P any] struct {
+	less   func(a, b P) bool
+	ring   ringBuffer[P]
+	heap   *PriorityQueue[any, P]
+	inRing bool
+	pinned bool
+	streak int
+	newest P
+	// handled 表示堆中可能存在已发放句柄的任务，此时不会迁移回环形缓冲区，以免句柄失效。
+	handled bool
+	// migrated 是在环形缓冲区与堆之间迁移的次数
+	migrated int
+}
+
+func newHybridStore[P any](less func(a, b P) bool, ordering ChannelOrdering) *hybridStore[P] {
+	return &hybridStore[P]{
+		less:   less,
+		heap:   NewPriorityQueueFunc[any](less, WithStableOrder()),
+		inRing: ordering == OrderingMonotonic,
+		pinned: ordering == OrderingHeap,
+	}
+}
+
+func (s *hybridStore[P]) push(value any, NTP P) {
+	inOrder := s.len() == 0 || !s.less(NTP, s.newest)
+	if inOrder {
+		s.newest = NTP
+	}
+
+	if s.inRing {
+		if inOrder {
+			s.ring.push(value, NTP)
+			return
+		}
+		// 出现乱序任务，将环形缓冲区中的任务全部迁移到堆中。
+		for s.ring.len() > 0 {
+			v, ntp := s.ring.pop()
+			s.heap.push(v, ntp)
+		}
+		s.inRing = false
+		s.streak = 0
+		s.migrated++
+	}
+
+	s.heap.push(value, NTP)
+	if s.pinned || s.handled {
+		return
+	}
+	if !inOrder {
+		s.streak = 0
+		return
+	}
+	// 所需的连续有序推入次数不少于堆中的任务数，使迁移的代价均摊到每次推入上，
+	// 以免时间戳轻微乱序的通道在两种存储之间反复迁移。
+	if s.streak++; s.streak >= max(monotonicStreak, int(s.heap.size())) {
+		// 连续有序推入，堆中的任务按时间顺序迁移到环形缓冲区。
+		for s.heap.size() > 0 {
+			v, ntp, _ := s.heap.pop()
+			s.ring.push(v, ntp)
+		}
+		s.inRing = true
+		s.streak = 0
+		s.migrated++
+	}
+}
+
+// pushHandle 推入任务并返回其句柄。只有堆支持句柄，因此会先将环形缓冲区中的任务迁移到堆中，
+// 并在堆清空之前停留在堆存储。
+func (s *hybridStore[P]) pushHandle(value any, NTP P) (Handle, bool) {
+	if s.inRing {
+		for s.ring.len() > 0 {
+			v, ntp := s.ring.pop()
+			s.heap.push(v, ntp)
+		}
+		s.inRing = false
+		s.streak = 0
+		s.migrated++
+	}
+	if s.len() == 0 || !s.less(NTP, s.newest) {
+		s.newest = NTP
+	}
+	s.handled = true
+	return s.heap.push(value, NTP), true
+}
+
+// update 修改句柄h对应任务的时间戳。
+func (s *hybridStore[P]) update(h Handle, NTP P) bool {
+	if s.inRing || !s.heap.update(h, NTP) {
+		return false
+	}
+	if !s.less(NTP, s.newest) {
+		s.newest = NTP
+	}
+	return true
+}
+
+// remove 移除并返回句柄h对应的任务。
+func (s *hybridStore[P]) remove(h Handle) (value any, NTP P, ok bool) {
+	if s.inRing {
+		return nil, NTP, false
+	}
+	value, NTP, ok = s.heap.remove(h)
+	if s.heap.size() == 0 {
+		s.handled = false
+	}
+	return value, NTP, ok
+}
+
+func (s *hybridStore[P]) pop() (value any, NTP P, ok bool) {
+	if s.inRing {
+		if s.ring.len() == 0 {
+			return nil, NTP, false
+		}
+		value, NTP = s.ring.pop()
+		return value, NTP, true
+	}
+	value, NTP, ok = s.heap.pop()
+	if s.heap.size() == 0 {
+		s.handled = false
+	}
+	return value, NTP, ok
+}
+
+func (s *hybridStore[P]) head() (value any, NTP P, ok bool) {
+	if s.inRing {
+		if s.ring.len() == 0 {
+			return nil, NTP, false
+		}
+		value, NTP = s.ring.front()
+		return value, NTP, true
+	}
+	return s.heap.head()
+}
+
+func (s *hybridStore[P]) len() int {
+	if s.inRing {
+		return s.ring.len()
+	}
+	return int(s.heap.size())
+}
+
+func (s *hybridStore[P]) concurrent() bool {
+	return false
+}
+
+func (s *hybridStore[P]) migrations() int {
+	return s.migrated
+}
+
+// ringBuffer 是可增长的环形缓冲区，容量总是2的幂。
+type ringBuffer[P any] struct {
+	items []Item[P]
+	first int
+	count int
+}
+
+func (r *ringBuffer[P]) len() int {
+	return r.count
+}
+
+func (r *ringBuffer[P]) push(value any, NTP P) {
+	if r.count == len(r.items) {
+		r.grow()
+	}
+	r.items[(r.first+r.count)&(len(r.items)-1)] = Item[P]{Value: value, NTP: NTP}
+	r.count++
+}
+
+func (r *ringBuffer[P]) pop() (value any, NTP P) {
+	item := r.items[r.first]
+	r.items[r.first] = Item[P]{}
+	r.first = (r.first + 1) & (len(r.items) - 1)
+	r.count--
+	return item.Value, item.NTP
+}
+
+func (r *ringBuffer[P]) front() (value any, NTP P) {
+	item := r.items[r.first]
+	return item.Value, item.NTP
+}
+
+func (r *ringBuffer[P]) grow() {
+	size := 2 * len(r.items)
+	if size == 0 {
+		size = 16
+	}
+	items := make([]Item[P], size)
+	for i := 0; i < r.count; i++ {
+		items[i] = r.items[(r.first+i)&(len(r.items)-1)]
+	}
+	r.items = items
+	r.first = 0
+}
@@ -0,0 +1,165 @@
+package core
+
+import "sync"
+
+// LifecycleEventKind 是生命周期事件的类型。
+type LifecycleEventKind int
+
+const (
+	// EventChannelCreated 表示通道已被CreateChannel创建。
+	EventChannelCreated LifecycleEventKind = iota
+	// EventChannelFirstPush 表示通道收到了第一个任务。
+	EventChannelFirstPush
+	// EventChannelClosing 表示通道已被CloseChannel关闭，不再接收新任务。
+	EventChannelClosing
+	// EventChannelDrained 表示已关闭的通道中的任务已全部出队。
+	EventChannelDrained
+	// EventChannelRemoved 表示通道已从队列中移除。
+	EventChannelRemoved
+	// EventChannelPaused 表示通道已被PauseChannel暂停。
+	EventChannelPaused
+	// EventChannelResumed 表示通道已被ResumeChannel恢复。
+	EventChannelResumed
+	// EventSamplerStarted 表示采样器已开始采样。
+	EventSamplerStarted
+	// EventSamplerStopped 表示采样器已停止采样，采样goroutine已退出。
+	EventSamplerStopped
+)
+
+func (k LifecycleEventKind) String() string {
+	switch k {
+	case EventChannelCreated:
+		return "ChannelCreated"
+	case EventChannelFirstPush:
+		return "ChannelFirstPush"
+	case EventChannelClosing:
+		return "ChannelClosing"
+	case EventChannelDrained:
+		return "ChannelDrained"
+	case EventChannelRemoved:
+		return "ChannelRemoved"
+	case EventChannelPaused:
+		return "ChannelPaused"
+	case EventChannelResumed:
+		return "ChannelResumed"
+	case EventSamplerStarted:
+		return "SamplerStarted"
+	case EventSamplerStopped:
+		return "SamplerStopped"
+	}
+	return "Unknown"
+}
+
+// LifecycleEvent 是通道或采样器的生命周期事件。采样器事件的Key为空。
+//
+// Generation 是事件所属的通道代：关闭后在清空前重新创建的通道与旧通道键相同，代数加一，参见CreateChannel。
+type LifecycleEvent struct {
+	Kind       LifecycleEventKind
+	Key        string
+	Generation uint64
+}
+
+// (q *TemporalQueue[P]) OnLifecycleEvent 设置生命周期事件的回调，fn为nil时取消回调。
+//
+// 回调在事件发生时同步调用，与OnChannelState的回调一样不得调用队列的方法。需要在处理事件时操作队列的调用方应使用Subscribe。
+func (q *TemporalQueue[P]) OnLifecycleEvent(fn func(event LifecycleEvent)) {
+	if fn == nil {
+		q.onEvent.Store(nil)
+		return
+	}
+	q.onEvent.Store(&fn)
+}
+
+// (q *TemporalQueue[P]) Subscribe 订阅生命周期事件，返回按发生顺序接收事件的通道，以及取消订阅的函数。
+//
+// 事件先进入订阅自己的无界缓冲区，再由单独的goroutine转发，因此队列不会因订阅方处理缓慢而阻塞，事件也不会丢失。
+// 订阅方可以在处理事件时调用队列的方法。取消订阅后通道被关闭，尚未读取的事件被丢弃。
+func (q *TemporalQueue[P]) Subscribe() (events <-chan LifecycleEvent, cancel func()) {
+	s := &subscription{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+		out:  make(chan LifecycleEvent),
+	}
+	q.subsMu.Lock()
+	old := q.loadSubs()
+	subs := append(old[:len(old):len(old)], s)
+	q.subs.Store(&subs)
+	q.subsMu.Unlock()
+	go s.run()
+
+	var once sync.Once
+	return s.out, func() {
+		once.Do(func() {
+			q.subsMu.Lock()
+			subs := make([]*subscription, 0, len(q.loadSubs()))
+			for _, sub := range q.loadSubs() {
+				if sub != s {
+					subs = append(subs, sub)
+				}
+			}
+			q.subs.Store(&subs)
+			q.subsMu.Unlock()
+			close(s.done)
+		})
+	}
+}
+
+// loadSubs 返回当前的订阅列表。订阅列表在修改时整体替换，读取无需加锁。
+func (q *TemporalQueue[P]) loadSubs() []*subscription {
+	if subs := q.subs.Load(); subs != nil {
+		return *subs
+	}
+	return nil
+}
+
+// emit 将生命周期事件交给回调与所有订阅。
+func (q *TemporalQueue[P]) emit(kind LifecycleEventKind, key string, generation uint64) {
+	event := LifecycleEvent{Kind: kind, Key: key, Generation: generation}
+	if fn := q.onEvent.Load(); fn != nil {
+		(*fn)(event)
+	}
+	for _, s := range q.loadSubs() {
+		s.deliver(event)
+	}
+}
+
+// subscription 是Subscribe的一个订阅：deliver将事件追加到缓冲区，run在单独的goroutine中按顺序转发事件。
+type subscription struct {
+	mu      sync.Mutex
+	pending []LifecycleEvent
+	wake    chan struct{}
+	done    chan struct{}
+	out     chan LifecycleEvent
+}
+
+func (s *subscription) deliver(event LifecycleEvent) {
+	s.mu.Lock()
+	s.pending = append(s.pending, event)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscription) run() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		batch := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		for _, event := range batch {
+			select {
+			case s.out <- event:
+			case <-s.done:
+				return
+			}
+		}
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
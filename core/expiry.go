@@ -0,0 +1,170 @@
+package core
+
+import "time"
+
+// TTLReference 指定过期时间的参照时刻。
+type TTLReference int
+
+const (
+	// TTLFromNewest 以队列中最新推入任务的时间戳为参照，适用于消费者落后于生产者的场景。
+	TTLFromNewest TTLReference = iota
+	// TTLFromNow 以时间线的Now（对AsynchronousTemporalQueue即系统时间）为参照；时间线未提供Now时等同于TTLFromNewest。
+	TTLFromNow
+)
+
+// (q *TemporalQueue[P]) OnEvict 设置过期任务被逐出（或被ResumeChannel丢弃）时的回调，回调会收到任务所属的通道键、任务数据及其时间戳，可用于释放任务持有的缓冲区。
+//
+// 回调在执行逐出的goroutine（Pop、Head、ResumeChannel或后台清理goroutine）中同步执行，执行时可能持有队列的消费锁，回调中不应调用Pop、Head等消费函数。
+// fn为nil时取消回调。
+func (q *TemporalQueue[P]) OnEvict(fn func(key string, value any, NTP P)) {
+	if fn == nil {
+		q.onEvict.Store(nil)
+		return
+	}
+	q.onEvict.Store(&fn)
+}
+
+// (q *TemporalQueue[P]) Evicted 返回队列中因过期而被逐出的任务总数，包括ResumeChannel丢弃的积压任务。
+func (q *TemporalQueue[P]) Evicted() uint64 {
+	return q.evicted.Load()
+}
+
+// (q *TemporalQueue[P]) ChannelEvicted 返回与给定键（key）关联的通道中因过期而被逐出（或被ResumeChannel丢弃）的任务数，包括仍在出队的旧一代通道。通道不存在时返回0。
+func (q *TemporalQueue[P]) ChannelEvicted(key string) uint64 {
+	var n uint64
+	if v, ok := q.channelMap.Load(key); ok {
+		v.(*asynchronousTemporalQueueItem[P]).generations(func(item *asynchronousTemporalQueueItem[P]) bool {
+			n += item.evicted.Load()
+			return true
+		})
+	}
+	return n
+}
+
+// (q *TemporalQueue[P]) StartSweep 启动后台清理goroutine，每隔interval逐出所有通道中的过期任务。
+//
+// Pop与Head只会逐出参与比较的通道队首的过期任务，后台清理可保证长时间无人消费的通道也能及时释放过期任务。
+// 若后台清理已在运行，则先停止原有的清理goroutine。interval不为正时只停止原有的清理，不启动新的清理goroutine。
+func (q *TemporalQueue[P]) StartSweep(interval time.Duration) {
+	// 停止原有的清理与启动新的清理在同一次加锁内完成，并发的StartSweep不会遗漏原有清理goroutine的停止通道
+	q.sweepMu.Lock()
+	defer q.sweepMu.Unlock()
+	q.stopSweep()
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	q.sweepStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				q.sweep()
+			}
+		}
+	}()
+}
+
+// (q *TemporalQueue[P]) CloseSweep 停止后台清理goroutine。
+func (q *TemporalQueue[P]) CloseSweep() {
+	q.sweepMu.Lock()
+	defer q.sweepMu.Unlock()
+	q.stopSweep()
+}
+
+// stopSweep 停止后台清理goroutine。调用方须持有sweepMu。
+func (q *TemporalQueue[P]) stopSweep() {
+	if q.sweepStop != nil {
+		close(q.sweepStop)
+		q.sweepStop = nil
+	}
+}
+
+// sweep 逐出所有通道中的过期任务。
+func (q *TemporalQueue[P]) sweep() {
+	q.rangeItems(func(item *asynchronousTemporalQueueItem[P]) bool {
+		if evicted, n := q.expire(item); n > 0 {
+			q.refresh(item)
+			q.report(item.key, evicted)
+		}
+		return true
+	})
+}
+
+// observe 记录最新推入任务的时间戳，作为TTLFromNewest的参照时刻。
+func (q *TemporalQueue[P]) observe(NTP P) {
+	q.newestMu.Lock()
+	defer q.newestMu.Unlock()
+	if !q.hasNewest || q.timeline.Less(q.newest, NTP) {
+		q.newest = NTP
+		q.hasNewest = true
+	}
+}
+
+// eviction 记录一个通道中被逐出、尚未通过回调上报的任务。
+type eviction[P any] struct {
+	key   string
+	items []Item[P]
+}
+
+// expire 逐出通道项（item）队首所有已过期的任务，返回逐出的任务数。
+// 只有设置了OnEvict回调时才会记录被逐出的任务（evicted），调用方须在释放所持有的锁后通过report上报。
+//
+// 通道自身的过期设置优先于队列的过期设置。由于通道项按时间戳排序，过期任务总是位于队首。
+func (q *TemporalQueue[P]) expire(item *asynchronousTemporalQueueItem[P]) (evicted []Item[P], count int) {
+	ttl, ref := q.options.ttl, q.options.ttlRef
+	if item.options.hasTTL {
+		ttl, ref = item.options.ttl, item.options.ttlRef
+	}
+	if ttl <= 0 {
+		return nil, 0
+	}
+
+	var bound P
+	if ref == TTLFromNow && q.timeline.Now != nil {
+		bound = q.timeline.Now()
+	} else {
+		q.newestMu.Lock()
+		newest, ok := q.newest, q.hasNewest
+		q.newestMu.Unlock()
+		if !ok {
+			return nil, 0
+		}
+		bound = newest
+	}
+
+	record := q.onEvict.Load() != nil
+	item.mu.Lock()
+	for {
+		value, NTP, ok := item.store.head()
+		if !ok || q.timeline.Distance(NTP, bound) <= ttl {
+			break
+		}
+		item.store.pop()
+		count++
+		if record {
+			evicted = append(evicted, Item[P]{Value: value, NTP: NTP})
+		}
+	}
+	item.mu.Unlock()
+
+	if count > 0 {
+		item.evicted.Add(uint64(count))
+		q.evicted.Add(uint64(count))
+	}
+	return evicted, count
+}
+
+// report 通过OnEvict回调上报通道key中被逐出的任务。
+func (q *TemporalQueue[P]) report(key string, evicted []Item[P]) {
+	if onEvict := q.onEvict.Load(); onEvict != nil {
+		for _, item := range evicted {
+			(*onEvict)(key, item.Value, item.NTP)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import "sync/atomic"
+
+// channelIDs 为每个通道项分配全局唯一的编号，用于识别句柄属于哪一代通道。
+var channelIDs atomic.Uint32
+
+// 通道的代（generation）：
+//
+// 对已关闭但尚未清空的通道再次调用CreateChannel时，会以同一个键创建新一代通道并替换channelMap中的旧通道，
+// 旧通道移入draining集合继续出队，清空后被移除。新一代通道在前代被移除之前照常接收任务，但不参与时间合并（gated），
+// 因此同一个键的任务总是先输出旧通道的、再输出新通道的，同一帧中也不会出现同一个键的两个任务。
+
+// replaceChannel 尝试以通道项（item）作为键key的新一代通道存入channelMap，并将键key加入其所属的各级分组。
+// 若键key已有打开的通道，不做任何事。
+//
+// channelMap的写入与分组成员的变更都在持有membershipMu时进行，因此retire移除旧通道与此处创建新通道不会交错。
+// EventChannelCreated在通道项存入channelMap之前发出，推入只能在此之后取得通道项，因此该事件总是先于EventChannelFirstPush。
+func (q *TemporalQueue[P]) replaceChannel(key string, item *asynchronousTemporalQueueItem[P]) {
+	q.membershipMu.Lock()
+	defer q.membershipMu.Unlock()
+	if v, loaded := q.channelMap.Load(key); loaded {
+		old := v.(*asynchronousTemporalQueueItem[P])
+		if old.loadState() == ChannelOpen {
+			return
+		}
+		// 旧通道仍在channelMap中，说明retire尚未移除它；retire会在移除后读取successor，让新通道加入时间合并。
+		item.generation = old.generation + 1
+		// 暂停作用于键的所有通道代，新一代通道继承旧通道的暂停状态
+		item.paused.Store(old.paused.Load())
+		item.pred.Store(old)
+		q.draining.Store(old, struct{}{})
+		old.successor.Store(item)
+	}
+	q.emit(EventChannelCreated, key, item.generation)
+	q.channelMap.Store(key, item)
+	q.joinGroups(key)
+}
+
+// gated 返回通道项是否因前代通道尚未移除而不参与时间合并。
+func (item *asynchronousTemporalQueueItem[P]) gated() bool {
+	pred := item.pred.Load()
+	return pred != nil && pred.loadState() != ChannelRemoved
+}
+
+// oldest 返回通道项（item）尚未移除的最早一代通道，即当前参与时间合并的那一代。
+func (item *asynchronousTemporalQueueItem[P]) oldest() *asynchronousTemporalQueueItem[P] {
+	for {
+		pred := item.pred.Load()
+		if pred == nil || pred.loadState() == ChannelRemoved {
+			return item
+		}
+		item = pred
+	}
+}
+
+// generations 依次调用fn处理通道项（item）及其所有尚未移除的前代通道，从最新一代开始。fn返回false时停止。
+func (item *asynchronousTemporalQueueItem[P]) generations(fn func(item *asynchronousTemporalQueueItem[P]) bool) {
+	for item != nil && item.loadState() != ChannelRemoved {
+		if !fn(item) {
+			return
+		}
+		item = item.pred.Load()
+	}
+}
+
+// rangeItems 依次调用fn处理channelMap中的通道项以及draining集合中仍在出队的旧通道。fn返回false时停止。
+func (q *TemporalQueue[P]) rangeItems(fn func(item *asynchronousTemporalQueueItem[P]) bool) {
+	more := true
+	q.channelMap.Range(func(key, value any) bool {
+		more = fn(value.(*asynchronousTemporalQueueItem[P]))
+		return more
+	})
+	if !more {
+		return
+	}
+	q.draining.Range(func(key, value any) bool {
+		return fn(key.(*asynchronousTemporalQueueItem[P]))
+	})
+}
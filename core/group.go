@@ -0,0 +1,242 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// GroupSeparator 分隔通道键的层级：键为"rig1/cam0"的通道属于分组"rig1"，键为"rig1/cams/cam0"的通道同时属于"rig1"与"rig1/cams"。
+const GroupSeparator = "/"
+
+// ChannelGroup 是键以"分组名/"为前缀的所有通道构成的分组，由TemporalQueue.Group返回。
+//
+// 分组的Pop与Head只在组内通道之间进行时间合并，与全局的Pop以及其他分组互不干扰，组内通道仍存放在队列共享的channelMap中。
+type ChannelGroup[P any] struct {
+	q    *TemporalQueue[P]
+	name string
+}
+
+// groupMembers 记录分组当前的通道键与分组配置。keys在修改时整体替换，读取无需加锁；options的读写须持有mu。
+//
+// 成员记录的创建、修改与删除都在持有TemporalQueue的membershipMu时进行。既没有通道也没有配置的分组会被删除，
+// 因此ChannelGroup不保存成员记录，每次使用时按名称查找。
+type groupMembers struct {
+	mu      sync.Mutex
+	keys    atomic.Pointer[[]string]
+	options []ChannelOption
+}
+
+// GroupStats 是分组在某一时刻的统计信息，由ChannelGroup.Stats返回。
+type GroupStats[P any] struct {
+	// Channels 是分组中的通道数，Open与Closing分别是其中处于ChannelOpen与ChannelClosing状态的通道数。
+	Channels int
+	Open     int
+	Closing  int
+	// Len 是分组中尚未出队的任务总数。
+	Len int
+	// Evicted 是分组中各通道因过期而被逐出（或被ResumeChannel丢弃）的任务总数。
+	Evicted uint64
+	// Head 是分组中最早的队首时间戳，HasHead为false时分组为空。
+	Head    P
+	HasHead bool
+}
+
+// (q *TemporalQueue[P]) Group 返回名为name的通道分组。分组无需事先创建，键以name+GroupSeparator为前缀的通道都属于该分组。
+func (q *TemporalQueue[P]) Group(name string) *ChannelGroup[P] {
+	return &ChannelGroup[P]{q: q, name: name}
+}
+
+// groupMembers 返回分组name的成员记录，不存在时创建。调用方须持有membershipMu。
+func (q *TemporalQueue[P]) groupMembers(name string) *groupMembers {
+	if v, ok := q.groups.Load(name); ok {
+		return v.(*groupMembers)
+	}
+	v, _ := q.groups.LoadOrStore(name, &groupMembers{})
+	return v.(*groupMembers)
+}
+
+// ancestors 依次调用fn处理键key所属的各级分组名，从最外层开始。
+func ancestors(key string, fn func(name string)) {
+	for i := 0; i < len(key); {
+		j := strings.Index(key[i:], GroupSeparator)
+		if j < 0 {
+			return
+		}
+		fn(key[:i+j])
+		i += j + len(GroupSeparator)
+	}
+}
+
+// groupOptions 返回键key所属的各级分组的配置，外层分组的配置在前，以便内层分组覆盖。
+func (q *TemporalQueue[P]) groupOptions(key string) []ChannelOption {
+	var opts []ChannelOption
+	ancestors(key, func(name string) {
+		if v, ok := q.groups.Load(name); ok {
+			members := v.(*groupMembers)
+			members.mu.Lock()
+			opts = append(opts, members.options...)
+			members.mu.Unlock()
+		}
+	})
+	return opts
+}
+
+// joinGroups 将键key加入其所属的各级分组。调用方须持有membershipMu。
+func (q *TemporalQueue[P]) joinGroups(key string) {
+	ancestors(key, func(name string) {
+		q.groupMembers(name).update(func(keys []string) []string {
+			i := sort.SearchStrings(keys, key)
+			if i < len(keys) && keys[i] == key {
+				return keys
+			}
+			next := make([]string, 0, len(keys)+1)
+			next = append(next, keys[:i]...)
+			next = append(next, key)
+			return append(next, keys[i:]...)
+		})
+	})
+}
+
+// leaveGroups 将键key移出其所属的各级分组，并删除因此变为空且没有配置的分组。调用方须持有membershipMu。
+func (q *TemporalQueue[P]) leaveGroups(key string) {
+	ancestors(key, func(name string) {
+		v, ok := q.groups.Load(name)
+		if !ok {
+			return
+		}
+		members := v.(*groupMembers)
+		members.update(func(keys []string) []string {
+			i := sort.SearchStrings(keys, key)
+			if i == len(keys) || keys[i] != key {
+				return keys
+			}
+			next := make([]string, 0, len(keys)-1)
+			next = append(next, keys[:i]...)
+			return append(next, keys[i+1:]...)
+		})
+		q.pruneGroup(name, members)
+	})
+}
+
+// pruneGroup 若分组name既没有通道也没有配置，则删除其成员记录。调用方须持有membershipMu。
+func (q *TemporalQueue[P]) pruneGroup(name string, members *groupMembers) {
+	members.mu.Lock()
+	empty := len(members.load()) == 0 && len(members.options) == 0
+	members.mu.Unlock()
+	if empty {
+		q.groups.CompareAndDelete(name, members)
+	}
+}
+
+// update 以fn返回的新切片替换分组的通道键。
+func (m *groupMembers) update(fn func(keys []string) []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := fn(m.load())
+	m.keys.Store(&keys)
+}
+
+func (m *groupMembers) load() []string {
+	if m == nil {
+		return nil
+	}
+	if keys := m.keys.Load(); keys != nil {
+		return *keys
+	}
+	return nil
+}
+
+// (g *ChannelGroup[P]) Name 返回分组名。
+func (g *ChannelGroup[P]) Name() string {
+	return g.name
+}
+
+// (g *ChannelGroup[P]) Keys 返回分组中所有通道的完整键，按键排序。
+func (g *ChannelGroup[P]) Keys() []string {
+	return append([]string(nil), g.members().load()...)
+}
+
+// members 返回分组当前的成员记录，分组没有通道也没有配置时返回nil。
+func (g *ChannelGroup[P]) members() *groupMembers {
+	if v, ok := g.q.groups.Load(g.name); ok {
+		return v.(*groupMembers)
+	}
+	return nil
+}
+
+// (g *ChannelGroup[P]) Group 返回分组中名为name的子分组。
+func (g *ChannelGroup[P]) Group(name string) *ChannelGroup[P] {
+	return g.q.Group(g.name + GroupSeparator + name)
+}
+
+// (g *ChannelGroup[P]) CreateChannel 在分组中创建键为"分组名/key"的通道，参见TemporalQueue.CreateChannel。
+func (g *ChannelGroup[P]) CreateChannel(key string, opts ...ChannelOption) {
+	g.q.CreateChannel(g.name+GroupSeparator+key, opts...)
+}
+
+// (g *ChannelGroup[P]) Push 向分组中键为"分组名/key"的通道添加任务，参见TemporalQueue.Push。
+func (g *ChannelGroup[P]) Push(key string, value any, NTP P) {
+	g.q.Push(g.name+GroupSeparator+key, value, NTP)
+}
+
+// (g *ChannelGroup[P]) Configure 设置分组的通道配置，此后在分组（包括其子分组）中创建的通道都会先应用这些配置，
+// 再应用CreateChannel传入的配置。已存在的通道不受影响。再次调用会替换之前的分组配置。
+func (g *ChannelGroup[P]) Configure(opts ...ChannelOption) {
+	g.q.membershipMu.Lock()
+	defer g.q.membershipMu.Unlock()
+	members := g.q.groupMembers(g.name)
+	members.mu.Lock()
+	members.options = append([]ChannelOption(nil), opts...)
+	members.mu.Unlock()
+	g.q.pruneGroup(g.name, members)
+}
+
+// (g *ChannelGroup[P]) Pop 只在分组内的通道之间进行时间合并，弹出队首时间戳最早的一组任务，参见TemporalQueue.PopFrom。
+// 返回的映射以通道的完整键为键。
+func (g *ChannelGroup[P]) Pop() (values map[string]any, NTP P, ok bool) {
+	return g.q.takeFrom(g.members().load(), true)
+}
+
+// (g *ChannelGroup[P]) Head 返回分组内队首时间戳最早的一组任务但不弹出。返回的映射可通过TemporalQueue.Release归还。
+func (g *ChannelGroup[P]) Head() (values map[string]any, NTP P, ok bool) {
+	return g.q.takeFrom(g.members().load(), false)
+}
+
+// (g *ChannelGroup[P]) Close 关闭分组中的所有通道，参见TemporalQueue.CloseChannel。
+func (g *ChannelGroup[P]) Close() {
+	for _, key := range g.members().load() {
+		g.q.CloseChannel(key)
+	}
+}
+
+// (g *ChannelGroup[P]) Stats 返回分组的统计信息。
+func (g *ChannelGroup[P]) Stats() GroupStats[P] {
+	var stats GroupStats[P]
+	for _, key := range g.members().load() {
+		v, ok := g.q.channelMap.Load(key)
+		if !ok {
+			continue
+		}
+		item := v.(*asynchronousTemporalQueueItem[P])
+		stats.Channels++
+		switch item.loadState() {
+		case ChannelOpen:
+			stats.Open++
+		case ChannelClosing:
+			stats.Closing++
+		}
+		// 仍在出队的旧一代通道计入同一个键
+		item.generations(func(gen *asynchronousTemporalQueueItem[P]) bool {
+			info := g.q.channelInfo(gen)
+			stats.Len += info.Len
+			stats.Evicted += gen.evicted.Load()
+			if info.HasHead && (!stats.HasHead || g.q.timeline.Less(info.Head, stats.Head)) {
+				stats.Head, stats.HasHead = info.Head, true
+			}
+			return true
+		})
+	}
+	return stats
+}
@@ -0,0 +1,64 @@
+package core
+
+// (q *TemporalQueue[P]) PushHandle 与Push相同，但返回新任务的句柄，之后可通过Retract撤回该任务或通过Retime修改其时间戳。
+//
+// 句柄只在发放它的通道上有效，任务被弹出、逐出或撤回后句柄随之失效；通道关闭后被同一个键的新一代通道替换时，
+// 旧通道发放的句柄在其清空前仍然有效。由于只有堆存储支持句柄，推入带句柄的任务会使通道停留在堆存储，直到通道中的任务全部出队。
+// 若通道不存在、已关闭或其存储不支持句柄（StorageSkipList），返回false。
+func (q *TemporalQueue[P]) PushHandle(key string, value any, NTP P) (Handle, bool) {
+	if item, ok := q.loadChannel(key); ok {
+		return q.pushItem(item, value, NTP, true)
+	}
+	return Handle{}, false
+}
+
+// (q *TemporalQueue[P]) Retract 从与给定键（key）关联的通道中撤回句柄h对应的任务（例如时间戳打错的任务），并返回该任务的数据。
+//
+// 若通道不存在或任务已不在通道中，ok为false。
+func (q *TemporalQueue[P]) Retract(key string, h Handle) (value any, ok bool) {
+	item, ok := q.handleOwner(key, h)
+	if !ok {
+		return nil, false
+	}
+	item.mu.Lock()
+	value, _, ok = item.store.remove(h)
+	item.mu.Unlock()
+	if ok {
+		q.refresh(item)
+	}
+	return value, ok
+}
+
+// (q *TemporalQueue[P]) Retime 将与给定键（key）关联的通道中句柄h对应任务的时间戳修改为NTP，例如在时钟校正之后重新定时。
+//
+// 若通道不存在或任务已不在通道中，返回false。
+func (q *TemporalQueue[P]) Retime(key string, h Handle, NTP P) bool {
+	item, ok := q.handleOwner(key, h)
+	if !ok {
+		return false
+	}
+	item.mu.Lock()
+	ok = item.store.update(h, NTP)
+	item.mu.Unlock()
+	if ok {
+		q.refresh(item)
+		q.observe(NTP)
+	}
+	return ok
+}
+
+// handleOwner 在与给定键（key）关联的通道及其仍在出队的旧一代通道中查找发放句柄h的通道项。
+func (q *TemporalQueue[P]) handleOwner(key string, h Handle) (owner *asynchronousTemporalQueueItem[P], ok bool) {
+	v, ok := q.channelMap.Load(key)
+	if !ok {
+		return nil, false
+	}
+	v.(*asynchronousTemporalQueueItem[P]).generations(func(item *asynchronousTemporalQueueItem[P]) bool {
+		if item.id == h.owner {
+			owner = item
+			return false
+		}
+		return true
+	})
+	return owner, owner != nil
+}
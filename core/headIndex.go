@@ -0,0 +1,108 @@
+package core
+
+// headIndex 是以通道队首时间戳为键、以通道项为元素的最小堆，用于在O(log C)时间内找到队首最早的通道。
+//
+// 每个通道项记录自己在堆中的位置（indexPos，不在堆中时为-1）以及入堆时的队首时间戳（indexNTP），
+// 因此通道队首变化后可以原地调整其位置。headIndex不是并发安全的，调用方须持有TemporalQueue的indexMu。
+type headIndex[P any] struct {
+	less  func(a, b P) bool
+	nodes []*asynchronousTemporalQueueItem[P]
+}
+
+// len 返回堆中的通道数。
+func (h *headIndex[P]) len() int {
+	return len(h.nodes)
+}
+
+// min 返回队首时间戳最早的通道项。
+func (h *headIndex[P]) min() (*asynchronousTemporalQueueItem[P], bool) {
+	if len(h.nodes) == 0 {
+		return nil, false
+	}
+	return h.nodes[0], true
+}
+
+// update 将通道项（item）以队首时间戳NTP加入堆中，若其已在堆中则调整其位置。
+func (h *headIndex[P]) update(item *asynchronousTemporalQueueItem[P], NTP P) {
+	if item.indexPos < 0 {
+		item.indexNTP = NTP
+		item.indexPos = len(h.nodes)
+		h.nodes = append(h.nodes, item)
+		h.up(item.indexPos)
+		return
+	}
+	earlier := h.less(NTP, item.indexNTP)
+	item.indexNTP = NTP
+	if earlier {
+		h.up(item.indexPos)
+	} else {
+		h.down(item.indexPos)
+	}
+}
+
+// remove 将通道项（item）移出堆，若其不在堆中则什么也不做。
+func (h *headIndex[P]) remove(item *asynchronousTemporalQueueItem[P]) {
+	i := item.indexPos
+	if i < 0 {
+		return
+	}
+	last := len(h.nodes) - 1
+	h.swap(i, last)
+	h.nodes[last] = nil
+	h.nodes = h.nodes[:last]
+	item.indexPos = -1
+	if i < last {
+		h.down(i)
+		h.up(i)
+	}
+}
+
+// collect 将堆中所有队首时间戳等于NTP的通道项追加到dst并返回。
+//
+// 由于堆中父节点不晚于子节点，这些通道项构成以堆顶为根的连通子树，只需遍历该子树即可。
+func (h *headIndex[P]) collect(NTP P, dst []*asynchronousTemporalQueueItem[P]) []*asynchronousTemporalQueueItem[P] {
+	return h.collectFrom(0, NTP, dst)
+}
+
+func (h *headIndex[P]) collectFrom(i int, NTP P, dst []*asynchronousTemporalQueueItem[P]) []*asynchronousTemporalQueueItem[P] {
+	if i >= len(h.nodes) || h.less(NTP, h.nodes[i].indexNTP) {
+		return dst
+	}
+	dst = append(dst, h.nodes[i])
+	dst = h.collectFrom(2*i+1, NTP, dst)
+	return h.collectFrom(2*i+2, NTP, dst)
+}
+
+func (h *headIndex[P]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(h.nodes[i].indexNTP, h.nodes[parent].indexNTP) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *headIndex[P]) down(i int) {
+	for {
+		j := 2*i + 1
+		if j >= len(h.nodes) {
+			break
+		}
+		if j+1 < len(h.nodes) && h.less(h.nodes[j+1].indexNTP, h.nodes[j].indexNTP) {
+			j++
+		}
+		if !h.less(h.nodes[j].indexNTP, h.nodes[i].indexNTP) {
+			break
+		}
+		h.swap(i, j)
+		i = j
+	}
+}
+
+func (h *headIndex[P]) swap(i, j int) {
+	h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i]
+	h.nodes[i].indexPos = i
+	h.nodes[j].indexPos = j
+}
@@ -0,0 +1,97 @@
+package core
+
+import "sort"
+
+// ChannelInfo 是通道在某一时刻的状态快照，由Channels返回。
+type ChannelInfo[P any] struct {
+	// Key 是通道的键。
+	Key string
+	// Generation 是通道的代。关闭后在清空前重新创建的通道代数加一，旧一代通道在清空前也会出现在Channels中。
+	Generation uint64
+	// State 是通道的生命周期状态（ChannelOpen或ChannelClosing）。
+	State ChannelState
+	// Paused 表示通道已被PauseChannel暂停。
+	Paused bool
+	// Len 是通道中尚未出队的任务数。
+	Len int
+	// Migrations 是通道存储在环形缓冲区与堆之间迁移的次数，参见WithOrdering。StorageSkipList通道总是为0。
+	Migrations int
+	// Head 是通道队首任务的时间戳，HasHead为false时通道为空。
+	Head    P
+	HasHead bool
+	// LastPushed 是最近一次推入通道的任务的时间戳，HasLastPushed为false时通道尚未收到任务。
+	LastPushed    P
+	HasLastPushed bool
+	// LastPopped 是最近一次从通道弹出的任务的时间戳，HasLastPopped为false时通道尚未有任务出队。
+	// 过期被逐出或被撤回的任务不计入。
+	LastPopped    P
+	HasLastPopped bool
+	// Weight 是StartSample为通道配置的采样权重，HasWeight为false时通道没有权重。
+	Weight    float64
+	HasWeight bool
+}
+
+// (q *TemporalQueue[P]) Channels 返回队列中所有通道（包括仍在出队的旧一代通道）的状态快照，按通道键与代排序。
+//
+// 各通道的快照分别获取，并发推入或弹出时不同通道的快照可能来自不同时刻。
+func (q *TemporalQueue[P]) Channels() []ChannelInfo[P] {
+	infos := make([]ChannelInfo[P], 0)
+	q.rangeItems(func(item *asynchronousTemporalQueueItem[P]) bool {
+		infos = append(infos, q.channelInfo(item))
+		return true
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Key != infos[j].Key {
+			return infos[i].Key < infos[j].Key
+		}
+		return infos[i].Generation < infos[j].Generation
+	})
+	return infos
+}
+
+// channelInfo 返回通道项（item）的状态快照。
+func (q *TemporalQueue[P]) channelInfo(item *asynchronousTemporalQueueItem[P]) ChannelInfo[P] {
+	info := ChannelInfo[P]{Key: item.key, Generation: item.generation, Paused: item.paused.Load()}
+	item.mu.RLock()
+	info.State = item.loadState()
+	info.Len = item.store.len()
+	info.Migrations = item.store.migrations()
+	_, info.Head, info.HasHead = item.store.head()
+	info.LastPopped, info.HasLastPopped = item.lastPopped, item.hasLastPopped
+	item.mu.RUnlock()
+
+	item.lastMu.Lock()
+	info.LastPushed, info.HasLastPushed = item.lastPushed, item.hasLastPushed
+	item.lastMu.Unlock()
+
+	if weight, ok := q.sampleWeights.Load(item.key); ok {
+		info.Weight, info.HasWeight = weight.(float64)
+	}
+	return info
+}
+
+// (q *TemporalQueue[P]) Len 返回所有通道中尚未出队的任务总数。
+//
+// 与Empty不同，Len在采样模式下也只统计通道中的原始任务，不包括采样输出中等待读取的帧。
+func (q *TemporalQueue[P]) Len() int {
+	n := 0
+	q.rangeItems(func(item *asynchronousTemporalQueueItem[P]) bool {
+		n += item.len()
+		return true
+	})
+	return n
+}
+
+// (q *TemporalQueue[P]) ChannelLen 返回与给定键（key）关联的通道（包括仍在出队的旧一代通道）中尚未出队的任务数。若通道不存在，返回0与false。
+func (q *TemporalQueue[P]) ChannelLen(key string) (int, bool) {
+	v, ok := q.channelMap.Load(key)
+	if !ok {
+		return 0, false
+	}
+	n := 0
+	v.(*asynchronousTemporalQueueItem[P]).generations(func(item *asynchronousTemporalQueueItem[P]) bool {
+		n += item.len()
+		return true
+	})
+	return n, true
+}
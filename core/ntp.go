@@ -0,0 +1,61 @@
+package core
+
+import "time"
+
+// ntpUnixOffset 是NTP纪元（1900-01-01）与Unix纪元（1970-01-01）之间相差的秒数。
+const ntpUnixOffset = 2208988800
+
+// ntpEraPivot 是NTP秒字段的最高位。按照RFC 4330的约定，最高位为0的秒字段属于2036年之后的第1纪元。
+const ntpEraPivot = 1 << 31
+
+// UnixNanoToNTP64 将Unix纳秒时间戳转换为NTP 32.32定点格式的64位时间戳（高32位为秒，低32位为秒的小数部分）。
+//
+// 小数部分四舍五入到最近的1/2^32秒，其分辨率（约0.23纳秒）高于纳秒，
+// 因此对1968年至2104年之间的时间戳，UnixNanoToNTP64与NTP64ToUnixNano的往返转换是精确的。
+func UnixNanoToNTP64(NTP int64) uint64 {
+	secs := NTP / int64(time.Second)
+	nsec := NTP % int64(time.Second)
+	if nsec < 0 {
+		secs--
+		nsec += int64(time.Second)
+	}
+	frac := (uint64(nsec)<<32 + uint64(time.Second)/2) / uint64(time.Second)
+	return uint64(uint32(secs+ntpUnixOffset))<<32 | frac
+}
+
+// NTP64ToUnixNano 将NTP 32.32定点格式的64位时间戳转换为Unix纳秒时间戳，小数部分四舍五入到纳秒。
+//
+// 秒字段最高位为0的时间戳按RFC 4330视为2036年2月之后的第1纪元。
+func NTP64ToUnixNano(ntp uint64) int64 {
+	secs := ntp >> 32
+	if secs&ntpEraPivot == 0 {
+		secs += 1 << 32
+	}
+	nsec := (ntp&0xFFFFFFFF*uint64(time.Second) + 1<<31) >> 32
+	return (int64(secs)-ntpUnixOffset)*int64(time.Second) + int64(nsec)
+}
+
+// (q *AsynchronousTemporalQueue) PushNTP64 向与给定键（key）关联的通道添加一个带有NTP 32.32定点格式时间戳（ntp）的新任务（value）。
+//
+// 时间戳会先通过NTP64ToUnixNano转换为队列内部使用的Unix纳秒时间戳，其余行为与Push相同。
+func (q *AsynchronousTemporalQueue) PushNTP64(key string, value any, ntp uint64) {
+	q.Push(key, value, NTP64ToUnixNano(ntp))
+}
+
+// (q *AsynchronousTemporalQueue) PopNTP64 与Pop相同，但返回NTP 32.32定点格式的时间戳。
+func (q *AsynchronousTemporalQueue) PopNTP64() (values map[string]any, ntp uint64, ok bool) {
+	values, NTP, ok := q.Pop()
+	if !ok {
+		return nil, 0, false
+	}
+	return values, UnixNanoToNTP64(NTP), true
+}
+
+// (q *AsynchronousTemporalQueue) HeadNTP64 与Head相同，但返回NTP 32.32定点格式的时间戳。
+func (q *AsynchronousTemporalQueue) HeadNTP64() (values map[string]any, ntp uint64, ok bool) {
+	values, NTP, ok := q.Head()
+	if !ok {
+		return nil, 0, false
+	}
+	return values, UnixNanoToNTP64(NTP), true
+}
@@ -0,0 +1,52 @@
+package core
+
+import "time"
+
+// QueueOption 配置异步时间队列的行为，在创建队列时传入。
+type QueueOption func(*queueOptions)
+
+// ChannelOption 配置单个通道的行为，在CreateChannel时传入。
+type ChannelOption func(*channelOptions)
+
+type queueOptions struct {
+	ttl        time.Duration
+	ttlRef     TTLReference
+	autoCreate *autoCreate
+}
+
+type channelOptions struct {
+	ttl      time.Duration
+	ttlRef   TTLReference
+	hasTTL   bool
+	ordering ChannelOrdering
+	storage  ChannelStorage
+	priority int
+}
+
+// WithTTL 为队列中的所有通道设置过期时间。ttl为0表示任务永不过期。
+//
+// 过期时间以ref为参照：时间戳与参照时刻的距离超过ttl的任务会被逐出。通道可通过WithChannelTTL覆盖此设置。
+func WithTTL(ttl time.Duration, ref TTLReference) QueueOption {
+	return func(o *queueOptions) {
+		o.ttl = ttl
+		o.ttlRef = ref
+	}
+}
+
+// WithChannelTTL 为单个通道设置过期时间，覆盖队列的WithTTL设置。ttl为0表示该通道的任务永不过期。
+func WithChannelTTL(ttl time.Duration, ref TTLReference) ChannelOption {
+	return func(o *channelOptions) {
+		o.ttl = ttl
+		o.ttlRef = ref
+		o.hasTTL = true
+	}
+}
+
+// WithChannelPriority 设置通道的优先级，默认为0，数值越大优先级越高。
+//
+// 时间戳相同的多个通道的任务会合并为一帧，帧内各通道按优先级从高到低排列（优先级相同时按通道键排列），参见Frame.Keys与OrderedKeys。
+func WithChannelPriority(priority int) ChannelOption {
+	return func(o *channelOptions) {
+		o.priority = priority
+	}
+}
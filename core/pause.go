@@ -0,0 +1,137 @@
+package core
+
+// ResumePolicy 决定ResumeChannel如何处理通道在暂停期间积压的任务。
+type ResumePolicy int
+
+const (
+	// ResumeDeliver 保留积压的任务，恢复后照常参与时间合并。
+	ResumeDeliver ResumePolicy = iota
+	// ResumeDrop 丢弃所有积压的任务。
+	ResumeDrop
+	// ResumeDropStale 丢弃时间戳早于当前输出时刻（队列最近一次弹出的最晚时间戳）的任务，
+	// 使恢复的通道不会输出早于其他通道已输出的帧。
+	ResumeDropStale
+)
+
+// (q *TemporalQueue[P]) PauseChannel 暂停与给定键（key）关联的通道，例如在相机重新标定期间。
+//
+// 暂停的通道照常接收推入的任务，但被移出队首索引：Pop、Head、PopN等时间合并不再考虑该通道，也不会等待它。
+// 与CloseChannel不同，暂停可以通过ResumeChannel恢复；已关闭的通道在暂停期间不会出队，直到恢复后清空才被移除。
+// 暂停作用于该键的所有通道代：仍在出队的旧一代通道随之暂停，暂停期间重新创建的新一代通道也处于暂停状态。
+// 若通道不存在或已暂停，返回false。
+func (q *TemporalQueue[P]) PauseChannel(key string) bool {
+	// 持有membershipMu，使暂停与CreateChannel创建新一代通道不会交错
+	q.membershipMu.Lock()
+	v, ok := q.channelMap.Load(key)
+	if !ok {
+		q.membershipMu.Unlock()
+		return false
+	}
+	item := v.(*asynchronousTemporalQueueItem[P])
+	if !item.paused.CompareAndSwap(false, true) {
+		q.membershipMu.Unlock()
+		return false
+	}
+	var gens []*asynchronousTemporalQueueItem[P]
+	item.generations(func(gen *asynchronousTemporalQueueItem[P]) bool {
+		gen.paused.Store(true)
+		gens = append(gens, gen)
+		return true
+	})
+	q.membershipMu.Unlock()
+
+	for _, gen := range gens {
+		q.refresh(gen)
+	}
+	q.emit(EventChannelPaused, key, item.generation)
+	return true
+}
+
+// (q *TemporalQueue[P]) ResumeChannel 恢复被PauseChannel暂停的通道，积压的任务按policy处理，返回被丢弃的任务数。
+// 仍在出队的旧一代通道随之恢复，其积压的任务同样按policy处理。若通道不存在或未暂停，ok为false。
+//
+// 被丢弃的任务按逐出处理：计入Evicted与ChannelEvicted，并通过OnEvict回调上报。
+func (q *TemporalQueue[P]) ResumeChannel(key string, policy ResumePolicy) (dropped int, ok bool) {
+	var output P
+	var hasOutput bool
+	if policy != ResumeDeliver {
+		q.indexMu.Lock()
+		output, hasOutput = q.output, q.hasOutput
+		q.indexMu.Unlock()
+	}
+
+	// 持有membershipMu，使恢复与CreateChannel创建新一代通道不会交错
+	q.membershipMu.Lock()
+	v, ok := q.channelMap.Load(key)
+	if !ok {
+		q.membershipMu.Unlock()
+		return 0, false
+	}
+	item := v.(*asynchronousTemporalQueueItem[P])
+	// 丢弃的任务与过期逐出的任务一样计入逐出计数，并在释放锁后通过OnEvict上报
+	record := q.onEvict.Load() != nil
+	var evicted []Item[P]
+	drop := func(gen *asynchronousTemporalQueueItem[P]) {
+		count := 0
+		for policy != ResumeDeliver {
+			value, NTP, ok := gen.store.head()
+			if !ok || policy == ResumeDropStale && (!hasOutput || !q.timeline.Less(NTP, output)) {
+				break
+			}
+			gen.store.pop()
+			count++
+			if record {
+				evicted = append(evicted, Item[P]{Value: value, NTP: NTP})
+			}
+		}
+		if count > 0 {
+			gen.evicted.Add(uint64(count))
+			q.evicted.Add(uint64(count))
+			dropped += count
+		}
+	}
+
+	// 先以CAS认领恢复操作，并发的ResumeChannel中只有一个会丢弃积压的任务；
+	// 认领与丢弃都在持有通道锁时进行，弹出者不会在丢弃完成前看到已恢复的通道。
+	item.mu.Lock()
+	if !item.paused.CompareAndSwap(true, false) {
+		item.mu.Unlock()
+		q.membershipMu.Unlock()
+		return 0, false
+	}
+	drop(item)
+	item.mu.Unlock()
+
+	// 新一代通道在旧一代通道被移除之前不参与时间合并，因此旧一代通道可以在认领之后逐个丢弃积压的任务并恢复
+	var gens []*asynchronousTemporalQueueItem[P]
+	item.generations(func(gen *asynchronousTemporalQueueItem[P]) bool {
+		gens = append(gens, gen)
+		return true
+	})
+	for _, gen := range gens {
+		if gen == item {
+			continue
+		}
+		gen.mu.Lock()
+		drop(gen)
+		gen.paused.Store(false)
+		gen.mu.Unlock()
+	}
+	q.membershipMu.Unlock()
+
+	// 先更新旧一代通道，使其清空后能被移除并让新一代通道加入时间合并
+	for i := len(gens) - 1; i >= 0; i-- {
+		q.refresh(gens[i])
+	}
+	q.report(key, evicted)
+	q.emit(EventChannelResumed, key, item.generation)
+	q.notify()
+	return dropped, true
+}
+
+// advanceOutput 记录队列弹出的任务的时间戳，作为ResumeDropStale的当前输出时刻。调用方须持有indexMu。
+func (q *TemporalQueue[P]) advanceOutput(NTP P) {
+	if !q.hasOutput || q.timeline.Less(q.output, NTP) {
+		q.output, q.hasOutput = NTP, true
+	}
+}
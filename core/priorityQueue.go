@@ -1,6 +1,7 @@
 package core
 
 import (
+	"iter"
 	"sync"
 
 	"golang.org/x/exp/constraints"
@@ -12,36 +13,136 @@ import (
 // oriented/ordered. Its type parameters `T` and `P`, respectively
 // specify the underlying value type and the underlying priority type.
 //
+// Items are stored by value in a contiguous slice whose capacity is reused
+// after pops, so a PriorityQueue in steady state doesn't allocate.
+//
 // Every operation on PriorityQueues are goroutine-safe.
-type PriorityQueue[T any, P constraints.Ordered] struct {
+type PriorityQueue[T any, P any] struct {
 	sync.RWMutex
-	items      []*priorityQueueItem[T, P]
+	items      []priorityQueueItem[T, P]
 	itemCount  uint
 	comparator func(lhs, rhs P) bool
+	tieBreaker func(lhs, rhs T) bool
+	stable     bool
+	nextSeq    uint64
+	arity      uint
+	slots      []prioritySlot
+	freeSlots  []uint32
+	capacity   uint
+	policy     CapacityPolicy
+	onEvict    func(value T, priority P)
+}
+
+// CapacityPolicy selects which item a bounded PriorityQueue evicts when a
+// push exceeds its capacity.
+type CapacityPolicy int
+
+const (
+	// EvictHead evicts the item that would be popped first. With a
+	// min-oriented PriorityQueue it keeps the K largest priorities, e.g. the
+	// K most recent frames when priorities are timestamps.
+	EvictHead CapacityPolicy = iota
+	// EvictTail evicts the item that would be popped last. With a
+	// max-oriented PriorityQueue it keeps the K largest priorities. Finding
+	// the tail takes *O(n)* time complexity.
+	EvictTail
+)
+
+// PriorityQueueOption configures a PriorityQueue at instantiation.
+type PriorityQueueOption func(*priorityQueueOptions)
+
+type priorityQueueOptions struct {
+	stable bool
+	arity  uint
 }
 
+// WithStableOrder makes the PriorityQueue pop items of equal priority in
+// insertion order (FIFO). Without it, ties come out in arbitrary heap order.
+func WithStableOrder() PriorityQueueOption {
+	return func(o *priorityQueueOptions) {
+		o.stable = true
+	}
+}
+
+// WithArity makes the PriorityQueue a d-ary heap, whose nodes have `d`
+// children instead of 2. A larger arity makes the heap shallower, which
+// speeds up pushes and keeps sibling comparisons within fewer cache lines,
+// at the cost of more comparisons per pop. 4 is usually a good choice for
+// large queues.
+//
+// It panics if `d` is less than 2.
+func WithArity(d int) PriorityQueueOption {
+	if d < 2 {
+		panic("core: PriorityQueue arity must be at least 2")
+	}
+	return func(o *priorityQueueOptions) {
+		o.arity = uint(d)
+	}
+}
+
+// Handle identifies an item pushed in a PriorityQueue. It stays valid until
+// the item leaves the queue, through Pop or Remove; afterwards every operation
+// on the Handle reports that the item isn't in the queue anymore.
+//
+// A Handle is only meaningful for the PriorityQueue that returned it. The zero
+// Handle never refers to an item.
+type Handle struct {
+	slot uint32
+	gen  uint32
+	// owner is left for the owner of the PriorityQueue to tell apart Handles
+	// of different PriorityQueues; the PriorityQueue itself ignores it.
+	owner uint32
+}
+
+// prioritySlot maps a Handle to the current position of its item in the heap.
+// A position of freeSlot means the slot is free.
+type prioritySlot struct {
+	pos uint
+	gen uint32
+}
+
+const freeSlot = ^uint(0)
+
 // NewPriorityQueue instantiates a new PriorityQueue with the provided comparison heuristic.
 // The package defines the `Max` and `Min` heuristic to define a max-oriented or
 // min-oriented heuristics, respectively.
-func NewPriorityQueue[T any, P constraints.Ordered](heuristic func(lhs, rhs P) bool) *PriorityQueue[T, P] {
-	items := make([]*priorityQueueItem[T, P], 1)
-	items[0] = nil
+func NewPriorityQueue[T any, P any](heuristic func(lhs, rhs P) bool, opts ...PriorityQueueOption) *PriorityQueue[T, P] {
+	options := priorityQueueOptions{arity: 2}
+	for _, opt := range opts {
+		opt(&options)
+	}
 
 	return &PriorityQueue[T, P]{
-		items:      items,
 		itemCount:  0,
 		comparator: heuristic,
+		stable:     options.stable,
+		arity:      options.arity,
 	}
 }
 
+// NewPriorityQueueFunc instantiates a new PriorityQueue ordered by `less`,
+// which returns whether priority `a` should be popped before priority `b`.
+// Unlike the heuristics of NewPriorityQueue, `less` reads in natural order, so
+// it suits priority types that aren't ordered, such as `time.Time` or
+// composite keys:
+//
+//	pq := NewPriorityQueueFunc[string](func(a, b time.Time) bool {
+//		return a.Before(b)
+//	})
+func NewPriorityQueueFunc[T any, P any](less func(a, b P) bool, opts ...PriorityQueueOption) *PriorityQueue[T, P] {
+	return NewPriorityQueue[T](func(lhs, rhs P) bool {
+		return less(rhs, lhs)
+	}, opts...)
+}
+
 // NewMaxPriorityQueue instantiates a new maximum oriented PriorityQueue.
-func NewMaxPriorityQueue[T any, P constraints.Ordered]() *PriorityQueue[T, P] {
-	return NewPriorityQueue[T](Maximum[P])
+func NewMaxPriorityQueue[T any, P constraints.Ordered](opts ...PriorityQueueOption) *PriorityQueue[T, P] {
+	return NewPriorityQueue[T](Maximum[P], opts...)
 }
 
 // NewMinPriorityQueue instantiates a new minimum oriented PriorityQueue.
-func NewMinPriorityQueue[T any, P constraints.Ordered]() *PriorityQueue[T, P] {
-	return NewPriorityQueue[T](Minimum[P])
+func NewMinPriorityQueue[T any, P constraints.Ordered](opts ...PriorityQueueOption) *PriorityQueue[T, P] {
+	return NewPriorityQueue[T](Minimum[P], opts...)
 }
 
 // Maximum returns whether `rhs` is greater than `lhs`.
@@ -60,16 +161,52 @@ func Minimum[T constraints.Ordered](lhs, rhs T) bool {
 	return lhs > rhs
 }
 
-// Push inserts the value in the PriorityQueue with the provided priority
-// in at most *O(log n)* time complexity.
-func (pq *PriorityQueue[T, P]) Push(value T, priority P) {
-	item := newPriorityQueueItem(value, priority)
-
+// SetTieBreaker installs a secondary comparison for items of equal priority:
+// tieBreaker returns whether `lhs` should be popped before `rhs`. Items that
+// the tie-breaker doesn't order either are then popped in insertion order if
+// the PriorityQueue is stable. A nil tieBreaker removes the secondary comparison.
+//
+// The tie-breaker must be installed while the PriorityQueue is empty.
+func (pq *PriorityQueue[T, P]) SetTieBreaker(tieBreaker func(lhs, rhs T) bool) {
 	pq.Lock()
 	defer pq.Unlock()
-	pq.items = append(pq.items, item)
-	pq.itemCount++
-	pq.swim(pq.size())
+	pq.tieBreaker = tieBreaker
+}
+
+// SetCapacity bounds the PriorityQueue to `capacity` items; zero removes the
+// bound. Once the PriorityQueue is full, every push evicts an item chosen by
+// `policy`, which may be the pushed item itself. Items beyond a reduced
+// capacity are evicted right away.
+//
+// `onEvict`, if not nil, is called with every evicted item once the
+// PriorityQueue is unlocked, so it may use the PriorityQueue.
+func (pq *PriorityQueue[T, P]) SetCapacity(capacity uint, policy CapacityPolicy, onEvict func(value T, priority P)) {
+	pq.Lock()
+	pq.capacity = capacity
+	pq.policy = policy
+	pq.onEvict = onEvict
+	evicted := pq.trim(nil)
+	pq.Unlock()
+	pq.report(onEvict, evicted)
+}
+
+// Push inserts the value in the PriorityQueue with the provided priority
+// in at most *O(log n)* time complexity, and returns a Handle to the item.
+//
+// If the PriorityQueue is bounded and full, an item is evicted according
+// to its CapacityPolicy; the Handle is invalid if the pushed item was evicted.
+func (pq *PriorityQueue[T, P]) Push(value T, priority P) Handle {
+	pq.Lock()
+	h := pq.push(value, priority)
+	if pq.capacity == 0 || pq.size() <= pq.capacity {
+		pq.Unlock()
+		return h
+	}
+	onEvict := pq.onEvict
+	evicted := pq.trim(nil)
+	pq.Unlock()
+	pq.report(onEvict, evicted)
+	return h
 }
 
 // Pop and return the highest or lowest priority item (depending on the
@@ -78,17 +215,154 @@ func (pq *PriorityQueue[T, P]) Push(value T, priority P) {
 func (pq *PriorityQueue[T, P]) Pop() (value T, priority P, ok bool) {
 	pq.Lock()
 	defer pq.Unlock()
+	return pq.pop()
+}
+
+// Head returns the highest or lowest priority item (depending on
+// the comparison heuristic of your PriorityQueue) from the PriorityQueue
+// in *O(1)* complexity.
+func (pq *PriorityQueue[T, P]) Head() (value T, priority P, ok bool) {
+	pq.RLock()
+	defer pq.RUnlock()
+	return pq.head()
+}
+
+// Update changes the priority of the item referred to by the Handle in at
+// most *O(log n)* time complexity. It returns false if the item isn't in
+// the PriorityQueue anymore.
+func (pq *PriorityQueue[T, P]) Update(h Handle, priority P) bool {
+	pq.Lock()
+	defer pq.Unlock()
+	return pq.update(h, priority)
+}
+
+// Remove removes and returns the item referred to by the Handle in at most
+// *O(log n)* time complexity. ok is false if the item isn't in the
+// PriorityQueue anymore.
+func (pq *PriorityQueue[T, P]) Remove(h Handle) (value T, priority P, ok bool) {
+	pq.Lock()
+	defer pq.Unlock()
+	return pq.remove(h)
+}
+
+// Contains returns whether the item referred to by the Handle is still
+// in the PriorityQueue.
+func (pq *PriorityQueue[T, P]) Contains(h Handle) bool {
+	pq.RLock()
+	defer pq.RUnlock()
+	_, ok := pq.lookup(h)
+	return ok
+}
+
+// Size returns the number of elements present in the PriorityQueue.
+func (pq *PriorityQueue[T, P]) Size() uint {
+	pq.RLock()
+	defer pq.RUnlock()
+	return pq.size()
+}
+
+// Empty returns whether the PriorityQueue is empty.
+func (pq *PriorityQueue[T, P]) Empty() bool {
+	pq.RLock()
+	defer pq.RUnlock()
+	return pq.size() == 0
+}
+
+// Clear removes every item from the PriorityQueue and invalidates their
+// Handles. The underlying storage is kept for reuse.
+func (pq *PriorityQueue[T, P]) Clear() {
+	pq.Lock()
+	defer pq.Unlock()
+	pq.clear()
+}
+
+// RemoveIf removes every item for which `pred` returns true in *O(n)* time
+// complexity, and returns the number of removed items.
+//
+// `pred` is called with the PriorityQueue locked and must not use it.
+func (pq *PriorityQueue[T, P]) RemoveIf(pred func(value T, priority P) bool) int {
+	pq.Lock()
+	defer pq.Unlock()
+	return pq.removeIf(pred)
+}
+
+// PriorityQueueItem is a value and its priority, as returned by Snapshot and PeekN.
+type PriorityQueueItem[T any, P any] struct {
+	Value    T
+	Priority P
+}
+
+// Snapshot returns a copy of every item of the PriorityQueue in the order
+// they would be popped, in *O(n log n)* time complexity. The PriorityQueue
+// is left untouched.
+func (pq *PriorityQueue[T, P]) Snapshot() []PriorityQueueItem[T, P] {
+	pq.RLock()
+	defer pq.RUnlock()
+	return pq.peekN(pq.size())
+}
+
+// PeekN returns a copy of the first `k` items of the PriorityQueue in the
+// order they would be popped, in *O(k log k)* time complexity. It returns
+// fewer items if the PriorityQueue holds less than `k`.
+func (pq *PriorityQueue[T, P]) PeekN(k int) []PriorityQueueItem[T, P] {
+	pq.RLock()
+	defer pq.RUnlock()
+	if k <= 0 {
+		return nil
+	}
+	return pq.peekN(min(uint(k), pq.size()))
+}
+
+// Merge pushes a copy of every item of `other` in the PriorityQueue; `other`
+// is left untouched. Handles of `other` don't refer to the copied items.
+func (pq *PriorityQueue[T, P]) Merge(other *PriorityQueue[T, P]) {
+	items := other.Snapshot()
+
+	pq.Lock()
+	for _, item := range items {
+		pq.push(item.Value, item.Priority)
+	}
+	onEvict := pq.onEvict
+	evicted := pq.trim(nil)
+	pq.Unlock()
+	pq.report(onEvict, evicted)
+}
+
+// All returns an iterator over the items of the PriorityQueue in the order
+// they would be popped. It iterates over a Snapshot taken when the iteration
+// starts, so the PriorityQueue can be modified during the iteration.
+func (pq *PriorityQueue[T, P]) All() iter.Seq2[T, P] {
+	return func(yield func(T, P) bool) {
+		for _, item := range pq.Snapshot() {
+			if !yield(item.Value, item.Priority) {
+				return
+			}
+		}
+	}
+}
 
+// push, pop, head, update and remove are the private counterparts of
+// Push, Pop, Head, Update and Remove. They're not goroutine-safe and assume
+// the caller already has acquired a lock on the PriorityQueue.
+func (pq *PriorityQueue[T, P]) push(value T, priority P) Handle {
+	item := newPriorityQueueItem(value, priority)
+	item.slot = pq.acquireSlot()
+	item.seq = pq.nextSeq
+	pq.nextSeq++
+	pq.items = append(pq.items, item)
+	pq.slots[item.slot].pos = pq.size()
+	pq.itemCount++
+	pq.swim(pq.size() - 1)
+	return Handle{slot: item.slot, gen: pq.slots[item.slot].gen}
+}
+
+func (pq *PriorityQueue[T, P]) pop() (value T, priority P, ok bool) {
 	if pq.size() < 1 {
 		ok = false
 		return
 	}
 
-	max := pq.items[1]
-	pq.exch(1, pq.size())
-	pq.items = pq.items[0:pq.size()]
-	pq.itemCount--
-	pq.sink(1)
+	max := pq.removeAt(0)
 
 	value = max.value
 	priority = max.priority
@@ -97,52 +371,221 @@ func (pq *PriorityQueue[T, P]) Pop() (value T, priority P, ok bool) {
 	return
 }
 
-// Head returns the highest or lowest priority item (depending on
-// the comparison heuristic of your PriorityQueue) from the PriorityQueue
-// in *O(1)* complexity.
-func (pq *PriorityQueue[T, P]) Head() (value T, priority P, ok bool) {
-	pq.RLock()
-	defer pq.RUnlock()
-
+func (pq *PriorityQueue[T, P]) head() (value T, priority P, ok bool) {
 	if pq.size() < 1 {
 		ok = false
 		return
 	}
 
-	value = pq.items[1].value
-	priority = pq.items[1].priority
+	value = pq.items[0].value
+	priority = pq.items[0].priority
 	ok = true
 
 	return
 }
 
-// Size returns the number of elements present in the PriorityQueue.
-func (pq *PriorityQueue[T, P]) Size() uint {
-	pq.RLock()
-	defer pq.RUnlock()
-	return pq.size()
+func (pq *PriorityQueue[T, P]) update(h Handle, priority P) bool {
+	k, ok := pq.lookup(h)
+	if !ok {
+		return false
+	}
+	pq.items[k].priority = priority
+	pq.swim(k)
+	pq.sink(pq.slots[h.slot].pos)
+	return true
 }
 
-// Empty returns whether the PriorityQueue is empty.
-func (pq *PriorityQueue[T, P]) Empty() bool {
-	pq.RLock()
-	defer pq.RUnlock()
-	return pq.size() == 0
+func (pq *PriorityQueue[T, P]) remove(h Handle) (value T, priority P, ok bool) {
+	k, ok := pq.lookup(h)
+	if !ok {
+		return
+	}
+	item := pq.removeAt(k)
+	return item.value, item.priority, true
+}
+
+func (pq *PriorityQueue[T, P]) clear() {
+	for k := range pq.items {
+		pq.releaseSlot(pq.items[k].slot)
+		pq.items[k] = priorityQueueItem[T, P]{}
+	}
+	pq.items = pq.items[0:0]
+	pq.itemCount = 0
+}
+
+// removeIf compacts the kept items in place, then restores the heap order
+// bottom-up.
+func (pq *PriorityQueue[T, P]) removeIf(pred func(value T, priority P) bool) int {
+	n := pq.size()
+	kept := uint(0)
+	for k := uint(0); k < n; k++ {
+		item := pq.items[k]
+		if pred(item.value, item.priority) {
+			pq.releaseSlot(item.slot)
+			continue
+		}
+		pq.items[kept] = item
+		pq.slots[item.slot].pos = kept
+		kept++
+	}
+	for k := kept; k < n; k++ {
+		pq.items[k] = priorityQueueItem[T, P]{}
+	}
+	pq.items = pq.items[0:kept]
+	pq.itemCount = kept
+	for k := kept / pq.arity; k > 0; k-- {
+		pq.sink(k)
+	}
+	if kept > 0 {
+		pq.sink(0)
+	}
+	return int(n - kept)
+}
+
+// peekN walks the heap from its root with an auxiliary heap of positions:
+// the next item in pop order is always a child of an item already returned.
+func (pq *PriorityQueue[T, P]) peekN(k uint) []PriorityQueueItem[T, P] {
+	if k == 0 {
+		return nil
+	}
+	items := make([]PriorityQueueItem[T, P], 0, k)
+	frontier := NewPriorityQueueFunc[struct{}](func(a, b uint) bool {
+		return pq.before(&pq.items[a], &pq.items[b])
+	})
+	frontier.push(struct{}{}, 0)
+	for uint(len(items)) < k {
+		_, pos, _ := frontier.pop()
+		items = append(items, PriorityQueueItem[T, P]{
+			Value:    pq.items[pos].value,
+			Priority: pq.items[pos].priority,
+		})
+		first := pq.arity*pos + 1
+		for child := first; child < first+pq.arity && child < pq.size(); child++ {
+			frontier.push(struct{}{}, child)
+		}
+	}
+	return items
+}
+
+// trim evicts items until the PriorityQueue fits its capacity, and appends
+// them to dst.
+func (pq *PriorityQueue[T, P]) trim(dst []PriorityQueueItem[T, P]) []PriorityQueueItem[T, P] {
+	for pq.capacity > 0 && pq.size() > pq.capacity {
+		k := uint(0)
+		if pq.policy == EvictTail {
+			k = pq.tail()
+		}
+		item := pq.removeAt(k)
+		if pq.onEvict != nil {
+			dst = append(dst, PriorityQueueItem[T, P]{Value: item.value, Priority: item.priority})
+		}
+	}
+	return dst
+}
+
+// tail returns the position of the item that would be popped last. It's
+// necessarily a leaf, so only the second half of the heap is scanned.
+func (pq *PriorityQueue[T, P]) tail() uint {
+	n := pq.size()
+	k := n - 1
+	if n == 1 {
+		return k
+	}
+	for j := (n-2)/pq.arity + 1; j < k; j++ {
+		if pq.less(j, k) {
+			k = j
+		}
+	}
+	return k
+}
+
+// report calls onEvict with every evicted item. The caller must not hold
+// the lock on the PriorityQueue.
+func (pq *PriorityQueue[T, P]) report(onEvict func(value T, priority P), evicted []PriorityQueueItem[T, P]) {
+	if onEvict == nil {
+		return
+	}
+	for _, item := range evicted {
+		onEvict(item.Value, item.Priority)
+	}
+}
+
+// removeAt removes the item at position k, restores the heap order and
+// releases the item's slot.
+func (pq *PriorityQueue[T, P]) removeAt(k uint) priorityQueueItem[T, P] {
+	n := pq.size() - 1
+	item := pq.items[k]
+	pq.exch(k, n)
+	pq.items[n] = priorityQueueItem[T, P]{}
+	pq.items = pq.items[0:n]
+	pq.itemCount--
+	if k < n {
+		pq.sink(k)
+		pq.swim(k)
+	}
+	pq.releaseSlot(item.slot)
+	return item
+}
+
+// lookup returns the position of the item referred to by the Handle.
+func (pq *PriorityQueue[T, P]) lookup(h Handle) (uint, bool) {
+	if int(h.slot) >= len(pq.slots) {
+		return 0, false
+	}
+	slot := pq.slots[h.slot]
+	if slot.pos == freeSlot || slot.gen != h.gen {
+		return 0, false
+	}
+	return slot.pos, true
+}
+
+// acquireSlot reuses a released slot if any, so that slots don't grow past
+// the largest size the PriorityQueue ever reached.
+func (pq *PriorityQueue[T, P]) acquireSlot() uint32 {
+	if n := len(pq.freeSlots); n > 0 {
+		slot := pq.freeSlots[n-1]
+		pq.freeSlots = pq.freeSlots[:n-1]
+		return slot
+	}
+	pq.slots = append(pq.slots, prioritySlot{pos: freeSlot, gen: 1})
+	return uint32(len(pq.slots) - 1)
+}
+
+// releaseSlot frees the slot and bumps its generation, invalidating every
+// Handle that refers to it.
+func (pq *PriorityQueue[T, P]) releaseSlot(slot uint32) {
+	pq.slots[slot].pos = freeSlot
+	pq.slots[slot].gen++
+	pq.freeSlots = append(pq.freeSlots, slot)
 }
 
+// swim and sink restore the heap order around position k. The heap is
+// 0-based: the children of k are at d*k+1 through d*k+d, where d is the arity.
 func (pq *PriorityQueue[T, P]) swim(k uint) {
-	for k > 1 && pq.less(k/2, k) {
-		pq.exch(k/2, k)
-		k /= 2
+	for k > 0 {
+		parent := (k - 1) / pq.arity
+		if !pq.less(parent, k) {
+			break
+		}
+		pq.exch(parent, k)
+		k = parent
 	}
 }
 
 func (pq *PriorityQueue[T, P]) sink(k uint) {
-	for 2*k <= pq.size() {
-		j := 2 * k
+	n := pq.size()
+	for {
+		first := pq.arity*k + 1
+		if first >= n {
+			break
+		}
 
-		if j < pq.size() && pq.less(j, j+1) {
-			j++
+		j := first
+		last := min(first+pq.arity, n)
+		for c := first + 1; c < last; c++ {
+			if pq.less(j, c) {
+				j = c
+			}
 		}
 
 		if !pq.less(k, j) {
@@ -160,23 +603,47 @@ func (pq *PriorityQueue[T, P]) size() uint {
 	return pq.itemCount
 }
 
+// less returns whether the item at `lhs` should be popped after the item at `rhs`.
 func (pq *PriorityQueue[T, P]) less(lhs, rhs uint) bool {
-	return pq.comparator(pq.items[lhs].priority, pq.items[rhs].priority)
+	return pq.before(&pq.items[rhs], &pq.items[lhs])
+}
+
+// before returns whether the item `a` should be popped before the item `b`.
+func (pq *PriorityQueue[T, P]) before(a, b *priorityQueueItem[T, P]) bool {
+	if pq.comparator(b.priority, a.priority) {
+		return true
+	}
+	if !pq.stable && pq.tieBreaker == nil || pq.comparator(a.priority, b.priority) {
+		return false
+	}
+	if pq.tieBreaker != nil {
+		if pq.tieBreaker(a.value, b.value) {
+			return true
+		}
+		if pq.tieBreaker(b.value, a.value) {
+			return false
+		}
+	}
+	return pq.stable && a.seq < b.seq
 }
 
 func (pq *PriorityQueue[T, P]) exch(lhs, rhs uint) {
 	pq.items[lhs], pq.items[rhs] = pq.items[rhs], pq.items[lhs]
+	pq.slots[pq.items[lhs].slot].pos = lhs
+	pq.slots[pq.items[rhs].slot].pos = rhs
 }
 
 // priorityQueueItem is the underlying PriorityQueue item container.
-type priorityQueueItem[T any, P constraints.Ordered] struct {
+type priorityQueueItem[T any, P any] struct {
 	value    T
 	priority P
+	slot     uint32
+	seq      uint64
 }
 
 // newPriorityQueue instantiates a new priorityQueueItem.
-func newPriorityQueueItem[T any, P constraints.Ordered](value T, priority P) *priorityQueueItem[T, P] {
-	return &priorityQueueItem[T, P]{
+func newPriorityQueueItem[T any, P any](value T, priority P) priorityQueueItem[T, P] {
+	return priorityQueueItem[T, P]{
 		value:    value,
 		priority: priority,
 	}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ShardedTemporalQueue 是分片的异步时间队列：通道按键的哈希分布到N个内部子队列（分片）上，
+// 不同分片上的通道的推入互不竞争，每个分片各自维护队首索引。
+//
+// Pop先取得各分片最早的队首时间戳，再从最早的分片中弹出时间戳相同的任务组合并为一帧，
+// 因此输出的时间顺序与单个TemporalQueue相同。分片不支持采样模式。
+//
+// 推入只锁定所属分片，而Pop与Head由consumeMu互斥：选出最早时间戳与从各分片取出任务组是一个整体，
+// 并发的消费者不会把同一帧拆开，队列非空时Pop也不会返回false。
+type ShardedTemporalQueue[P any] struct {
+	shards    []*TemporalQueue[P]
+	timeline  Timeline[P]
+	ready     chan struct{}
+	maps      sync.Pool
+	consumeMu sync.Mutex
+}
+
+// NewShardedTemporalQueue 创建一个以timeline描述时间戳、由shards个分片组成的异步时间队列实例。
+//
+// 参数：
+//
+//	timeline Timeline[P]: 时间戳类型P的时间线，其Less与Distance不能为nil。
+//	shards int: 分片数，不大于0时取runtime.GOMAXPROCS(0)。
+//	opts ...QueueOption: 可选的队列配置，应用于每个分片。
+func NewShardedTemporalQueue[P any](timeline Timeline[P], shards int, opts ...QueueOption) *ShardedTemporalQueue[P] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	s := &ShardedTemporalQueue[P]{
+		shards:   make([]*TemporalQueue[P], shards),
+		timeline: timeline,
+		ready:    make(chan struct{}, 1),
+	}
+	for i := range s.shards {
+		s.shards[i] = NewTemporalQueue(timeline, opts...)
+		// 所有分片共用同一个通知通道
+		s.shards[i].ready = s.ready
+	}
+	return s
+}
+
+// shard 返回与给定键（key）关联的通道所在的分片，以FNV-1a哈希选择。
+func (s *ShardedTemporalQueue[P]) shard(key string) *TemporalQueue[P] {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return s.shards[hash%uint32(len(s.shards))]
+}
+
+// (s *ShardedTemporalQueue[P]) CreateChannel 在键（key）所属的分片中创建一个新的通道，参见TemporalQueue.CreateChannel。
+func (s *ShardedTemporalQueue[P]) CreateChannel(key string, opts ...ChannelOption) {
+	s.shard(key).CreateChannel(key, opts...)
+}
+
+// (s *ShardedTemporalQueue[P]) CloseChannel 关闭与给定键（key）关联的通道，参见TemporalQueue.CloseChannel。
+func (s *ShardedTemporalQueue[P]) CloseChannel(key string) {
+	s.shard(key).CloseChannel(key)
+}
+
+// (s *ShardedTemporalQueue[P]) Push 向与给定键（key）关联的通道添加一个带有NTP时间戳的新任务（value），参见TemporalQueue.Push。
+func (s *ShardedTemporalQueue[P]) Push(key string, value any, NTP P) {
+	s.shard(key).Push(key, value, NTP)
+}
+
+// (s *ShardedTemporalQueue[P]) PushBatch 将一批任务（items）推入与给定键（key）关联的通道，参见TemporalQueue.PushBatch。
+func (s *ShardedTemporalQueue[P]) PushBatch(key string, items []Item[P]) {
+	s.shard(key).PushBatch(key, items)
+}
+
+// (s *ShardedTemporalQueue[P]) OnEvict 为所有分片设置任务过期被逐出时的回调，参见TemporalQueue.OnEvict。
+func (s *ShardedTemporalQueue[P]) OnEvict(fn func(key string, value any, NTP P)) {
+	for _, shard := range s.shards {
+		shard.OnEvict(fn)
+	}
+}
+
+// (s *ShardedTemporalQueue[P]) Ready 返回一个通知通道：每当任一分片有新任务可供Pop读取时，通道中会出现一个信号，参见TemporalQueue.Ready。
+func (s *ShardedTemporalQueue[P]) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// (s *ShardedTemporalQueue[P]) Pop 弹出所有分片中最早到期的任务，返回值与TemporalQueue.Pop相同。
+//
+// 函数先取得每个分片的最早队首时间戳并选出其中最早者（分片数通常很小，逐个比较即为k路归并），
+// 再从每个最早队首时间戳与之相同的分片中弹出该时间戳的任务组，合并到同一个结果映射中。
+func (s *ShardedTemporalQueue[P]) Pop() (values map[string]any, NTP P, ok bool) {
+	return s.take(true)
+}
+
+// (s *ShardedTemporalQueue[P]) Head 获取所有分片中最早到期的队首任务但不弹出，返回值与TemporalQueue.Head相同。
+func (s *ShardedTemporalQueue[P]) Head() (values map[string]any, NTP P, ok bool) {
+	return s.take(false)
+}
+
+func (s *ShardedTemporalQueue[P]) take(remove bool) (values map[string]any, NTP P, ok bool) {
+	s.consumeMu.Lock()
+	defer s.consumeMu.Unlock()
+
+	results := s.acquire()
+	for {
+		ok = false
+		for _, shard := range s.shards {
+			if head, found := shard.earliest(); found && (!ok || s.timeline.Less(head, NTP)) {
+				NTP, ok = head, true
+			}
+		}
+		if !ok {
+			s.maps.Put(results)
+			var zero P
+			return nil, zero, false
+		}
+		for _, shard := range s.shards {
+			shard.takeInto(results, remove, &NTP)
+		}
+		if len(results) > 0 {
+			return results, NTP, true
+		}
+		// 所选的任务在选出之后被撤回或逐出，重新选择
+	}
+}
+
+// (s *ShardedTemporalQueue[P]) Release 将Pop或Head返回的结果映射（values）归还给队列，参见TemporalQueue.Release。
+func (s *ShardedTemporalQueue[P]) Release(values map[string]any) {
+	if values == nil {
+		return
+	}
+	clear(values)
+	s.maps.Put(values)
+}
+
+func (s *ShardedTemporalQueue[P]) acquire() map[string]any {
+	if v := s.maps.Get(); v != nil {
+		return v.(map[string]any)
+	}
+	return make(map[string]any)
+}
+
+// (s *ShardedTemporalQueue[P]) Empty 返回所有分片是否都为空。
+func (s *ShardedTemporalQueue[P]) Empty() bool {
+	for _, shard := range s.shards {
+		if !shard.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// (s *ShardedTemporalQueue[P]) Len 返回所有分片中尚未出队的任务总数，参见TemporalQueue.Len。
+func (s *ShardedTemporalQueue[P]) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// (s *ShardedTemporalQueue[P]) ChannelLen 返回与给定键（key）关联的通道中尚未出队的任务数，参见TemporalQueue.ChannelLen。
+func (s *ShardedTemporalQueue[P]) ChannelLen(key string) (int, bool) {
+	return s.shard(key).ChannelLen(key)
+}
+
+// (s *ShardedTemporalQueue[P]) Channels 返回所有分片中所有通道的状态快照，与TemporalQueue.Channels一样按通道键与代排序。
+func (s *ShardedTemporalQueue[P]) Channels() []ChannelInfo[P] {
+	infos := make([]ChannelInfo[P], 0)
+	for _, shard := range s.shards {
+		infos = append(infos, shard.Channels()...)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Key != infos[j].Key {
+			return infos[i].Key < infos[j].Key
+		}
+		return infos[i].Generation < infos[j].Generation
+	})
+	return infos
+}
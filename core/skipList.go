@@ -0,0 +1,130 @@
+package core
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// skipListMaxLevel 是跳表的最大层数，以1/4的概率逐层晋升时足以容纳约4^24个任务。
+const skipListMaxLevel = 24
+
+// skipList 是按（时间戳，序号）排序的并发跳表，供StorageSkipList通道存储任务。
+//
+// 推入是无锁的：各层的后继指针都是原子指针，新节点自底层向上逐层通过CAS链接，底层链接成功即视为插入完成。
+// 多个推入可以在持有通道项读锁时并发进行，head与len也可与推入并发调用；
+// pop只在持有通道项写锁时调用，此时没有并发的推入，因此删除无需标记节点。
+//
+// 序号在推入时分配，使时间戳相同的任务按推入顺序出队。跳表不支持句柄。
+type skipList[P any] struct {
+	less func(a, b P) bool
+	// root 是不存储任务的头节点，拥有全部层
+	root  skipNode[P]
+	seq   atomic.Uint64
+	count atomic.Int64
+}
+
+type skipNode[P any] struct {
+	value any
+	NTP   P
+	seq   uint64
+	next  []atomic.Pointer[skipNode[P]]
+}
+
+func newSkipList[P any](less func(a, b P) bool) *skipList[P] {
+	s := &skipList[P]{less: less}
+	s.root.next = make([]atomic.Pointer[skipNode[P]], skipListMaxLevel)
+	return s
+}
+
+// before 返回节点a是否应排在节点b之前。
+func (s *skipList[P]) before(a, b *skipNode[P]) bool {
+	if s.less(a.NTP, b.NTP) {
+		return true
+	}
+	return !s.less(b.NTP, a.NTP) && a.seq < b.seq
+}
+
+// seek 从前驱pred开始在第level层向后查找，返回node在该层的前驱与后继。
+func (s *skipList[P]) seek(node, pred *skipNode[P], level int) (*skipNode[P], *skipNode[P]) {
+	succ := pred.next[level].Load()
+	for succ != nil && s.before(succ, node) {
+		pred = succ
+		succ = pred.next[level].Load()
+	}
+	return pred, succ
+}
+
+func (s *skipList[P]) push(value any, NTP P) {
+	// 以1/4的概率逐层晋升
+	level := 1 + bits.TrailingZeros64(rand.Uint64()|1<<(2*skipListMaxLevel-2))/2
+	node := &skipNode[P]{
+		value: value,
+		NTP:   NTP,
+		seq:   s.seq.Add(1),
+		next:  make([]atomic.Pointer[skipNode[P]], level),
+	}
+
+	var preds, succs [skipListMaxLevel]*skipNode[P]
+	pred := &s.root
+	for i := skipListMaxLevel - 1; i >= 0; i-- {
+		pred, succs[i] = s.seek(node, pred, i)
+		preds[i] = pred
+	}
+	for i := 0; i < level; i++ {
+		for {
+			node.next[i].Store(succs[i])
+			if preds[i].next[i].CompareAndSwap(succs[i], node) {
+				break
+			}
+			// 其他推入在同一位置插入了节点。没有并发删除，原前驱仍然有效，只需从它开始重新查找。
+			preds[i], succs[i] = s.seek(node, preds[i], i)
+		}
+	}
+	s.count.Add(1)
+}
+
+func (s *skipList[P]) pop() (value any, NTP P, ok bool) {
+	first := s.root.next[0].Load()
+	if first == nil {
+		return nil, NTP, false
+	}
+	// first是最早的节点，在它所在的每一层都是头节点的直接后继。
+	for i := range first.next {
+		s.root.next[i].Store(first.next[i].Load())
+	}
+	s.count.Add(-1)
+	return first.value, first.NTP, true
+}
+
+func (s *skipList[P]) head() (value any, NTP P, ok bool) {
+	first := s.root.next[0].Load()
+	if first == nil {
+		return nil, NTP, false
+	}
+	return first.value, first.NTP, true
+}
+
+func (s *skipList[P]) len() int {
+	return int(s.count.Load())
+}
+
+func (s *skipList[P]) concurrent() bool {
+	return true
+}
+
+func (s *skipList[P]) migrations() int {
+	return 0
+}
+
+func (s *skipList[P]) pushHandle(value any, NTP P) (Handle, bool) {
+	return Handle{}, false
+}
+
+func (s *skipList[P]) update(h Handle, NTP P) bool {
+	return false
+}
+
+func (s *skipList[P]) remove(h Handle) (value any, NTP P, ok bool) {
+	return nil, NTP, false
+}
@@ -0,0 +1,153 @@
+package core
+
+// ChannelState 是通道的生命周期状态。状态只会按Open→Closing→Drained→Removed的顺序前进。
+type ChannelState int32
+
+const (
+	// ChannelOpen 表示通道正常接收推入的任务。
+	ChannelOpen ChannelState = iota
+	// ChannelClosing 表示通道已被CloseChannel关闭：不再接收新任务，但已有的任务仍会照常被弹出。
+	ChannelClosing
+	// ChannelDrained 表示已关闭的通道中的任务已全部出队，通道即将被移除。
+	ChannelDrained
+	// ChannelRemoved 表示通道已从队列中移除，同名通道可以重新创建。
+	ChannelRemoved
+)
+
+func (s ChannelState) String() string {
+	switch s {
+	case ChannelOpen:
+		return "Open"
+	case ChannelClosing:
+		return "Closing"
+	case ChannelDrained:
+		return "Drained"
+	case ChannelRemoved:
+		return "Removed"
+	}
+	return "Unknown"
+}
+
+// SamplerState 是队列采样器的状态：Raw→Sampling→Stopping→Raw。
+type SamplerState int32
+
+const (
+	// SamplerRaw 表示队列未在采样，Pop直接弹出原始任务。
+	SamplerRaw SamplerState = iota
+	// SamplerSampling 表示采样goroutine正在运行，Pop弹出采样后的帧。
+	SamplerSampling
+	// SamplerStopping 表示CloseSample已被调用，正在等待采样goroutine退出。
+	SamplerStopping
+)
+
+func (s SamplerState) String() string {
+	switch s {
+	case SamplerRaw:
+		return "Raw"
+	case SamplerSampling:
+		return "Sampling"
+	case SamplerStopping:
+		return "Stopping"
+	}
+	return "Unknown"
+}
+
+// (q *TemporalQueue[P]) OnChannelState 设置通道状态转换时的回调，fn为nil时取消回调。
+//
+// 回调在状态转换时同步调用，调用时可能持有队列内部的锁，因此回调中不得调用队列的方法，且应尽快返回。
+func (q *TemporalQueue[P]) OnChannelState(fn func(key string, from, to ChannelState)) {
+	if fn == nil {
+		q.onChannelState.Store(nil)
+		return
+	}
+	q.onChannelState.Store(&fn)
+}
+
+// (q *TemporalQueue[P]) OnSamplerState 设置采样器状态转换时的回调，fn为nil时取消回调。
+//
+// 回调在状态转换时同步调用，与OnChannelState的回调一样不得调用队列的方法。
+func (q *TemporalQueue[P]) OnSamplerState(fn func(from, to SamplerState)) {
+	if fn == nil {
+		q.onSamplerState.Store(nil)
+		return
+	}
+	q.onSamplerState.Store(&fn)
+}
+
+// (q *TemporalQueue[P]) ChannelState 返回与给定键（key）关联的通道的状态。若通道不存在（包括已被移除），返回ChannelRemoved与false。
+func (q *TemporalQueue[P]) ChannelState(key string) (ChannelState, bool) {
+	if v, ok := q.channelMap.Load(key); ok {
+		return v.(*asynchronousTemporalQueueItem[P]).loadState(), true
+	}
+	return ChannelRemoved, false
+}
+
+// (q *TemporalQueue[P]) SamplerState 返回采样器的状态。
+func (q *TemporalQueue[P]) SamplerState() SamplerState {
+	return SamplerState(q.samplerState.Load())
+}
+
+// sampling 返回队列是否处于采样模式（包括正在停止采样）。处于采样模式时Pop从采样输出中读取帧。
+func (q *TemporalQueue[P]) sampling() bool {
+	return q.SamplerState() != SamplerRaw
+}
+
+// transitionSampler 将采样器的状态由from原子地转换为to，并调用状态转换回调。若当前状态不是from，返回false。
+func (q *TemporalQueue[P]) transitionSampler(from, to SamplerState) bool {
+	if !q.samplerState.CompareAndSwap(int32(from), int32(to)) {
+		return false
+	}
+	if fn := q.onSamplerState.Load(); fn != nil {
+		(*fn)(from, to)
+	}
+	switch to {
+	case SamplerSampling:
+		q.emit(EventSamplerStarted, "", 0)
+	case SamplerRaw:
+		q.emit(EventSamplerStopped, "", 0)
+	}
+	return true
+}
+
+// loadState 返回通道项的状态。
+func (item *asynchronousTemporalQueueItem[P]) loadState() ChannelState {
+	return ChannelState(item.state.Load())
+}
+
+// transition 将通道项（item）的状态由from原子地转换为to，并调用状态转换回调。若当前状态不是from，返回false。
+func (q *TemporalQueue[P]) transition(item *asynchronousTemporalQueueItem[P], from, to ChannelState) bool {
+	if !item.state.CompareAndSwap(int32(from), int32(to)) {
+		return false
+	}
+	if fn := q.onChannelState.Load(); fn != nil {
+		(*fn)(item.key, from, to)
+	}
+	switch to {
+	case ChannelClosing:
+		q.emit(EventChannelClosing, item.key, item.generation)
+	case ChannelDrained:
+		q.emit(EventChannelDrained, item.key, item.generation)
+	case ChannelRemoved:
+		q.emit(EventChannelRemoved, item.key, item.generation)
+	}
+	return true
+}
+
+// retire 移除已关闭且已清空的通道项（item）：Closing→Drained→Removed，并让其下一代通道加入时间合并。调用方须持有indexMu。
+func (q *TemporalQueue[P]) retire(item *asynchronousTemporalQueueItem[P]) {
+	if !q.transition(item, ChannelClosing, ChannelDrained) {
+		return
+	}
+	q.index.remove(item)
+	q.membershipMu.Lock()
+	if q.channelMap.CompareAndDelete(item.key, item) {
+		q.leaveGroups(item.key)
+	}
+	q.draining.Delete(item)
+	q.membershipMu.Unlock()
+	q.transition(item, ChannelDrained, ChannelRemoved)
+	if successor := item.successor.Load(); successor != nil {
+		// 下一代通道不再等待本通道，加入时间合并
+		q.reindex(successor)
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import "slices"
+
+// (q *TemporalQueue[P]) PopFrom 只在给定键（keys）的通道之间进行时间合并：弹出这些通道中队首时间戳最早的一组任务，
+// 返回值与Pop相同。其他通道不受影响，其他消费者仍可同时使用全局的Pop。
+//
+// 不存在的键与暂停的通道会被忽略。与Pop一样，时间戳晚于当前时刻（时间线的Now）的任务不会被弹出，返回的映射可通过Release归还。
+// PopFrom总是读取通道中的原始任务，不受采样模式影响。
+func (q *TemporalQueue[P]) PopFrom(keys ...string) (values map[string]any, NTP P, ok bool) {
+	return q.takeFrom(keys, true)
+}
+
+// (q *TemporalQueue[P]) HeadOf 返回与给定键（key）关联的通道的队首任务及其时间戳，但不弹出。
+// 若通道不存在、为空或队首晚于当前时刻（时间线的Now），ok为false。
+func (q *TemporalQueue[P]) HeadOf(key string) (value any, NTP P, ok bool) {
+	values, NTP, ok := q.takeFrom([]string{key}, false)
+	if !ok {
+		return nil, NTP, false
+	}
+	value = values[key]
+	q.Release(values)
+	return value, NTP, true
+}
+
+// takeFrom 是PopFrom与HeadOf的共同实现，与take一样在持有consumeMu与indexMu时进行，从而与全局的Pop互斥。
+func (q *TemporalQueue[P]) takeFrom(keys []string, remove bool) (values map[string]any, NTP P, ok bool) {
+	var pending []eviction[P]
+
+	q.consumeMu.Lock()
+	defer q.consumeMu.Unlock()
+	q.indexMu.Lock()
+	q.scratch = q.scratch[:0]
+	for _, key := range keys {
+		v, loaded := q.channelMap.Load(key)
+		if !loaded {
+			continue
+		}
+		// 只有尚未移除的最早一代通道参与时间合并
+		item := v.(*asynchronousTemporalQueueItem[P]).oldest()
+		if item.paused.Load() {
+			continue
+		}
+		if evicted, n := q.expire(item); n > 0 {
+			if len(evicted) > 0 {
+				pending = append(pending, eviction[P]{key: item.key, items: evicted})
+			}
+			q.reindex(item)
+		}
+		_, head, hasHead := item.head()
+		if !hasHead {
+			continue
+		}
+		if !ok || q.timeline.Less(head, NTP) {
+			NTP, ok = head, true
+			q.scratch = q.scratch[:0]
+		}
+		if !q.timeline.Less(NTP, head) && !slices.Contains(q.scratch, item) {
+			q.scratch = append(q.scratch, item)
+		}
+	}
+	if ok && q.timeline.Now != nil && q.timeline.Less(q.timeline.Now(), NTP) {
+		ok = false
+	}
+
+	results := q.acquire()
+	if ok {
+		for _, item := range q.scratch {
+			if !remove {
+				if value, _, hasHead := item.head(); hasHead {
+					results[item.key] = value
+				}
+				continue
+			}
+			item.mu.Lock()
+			if value, head, hasHead := item.store.head(); hasHead && !q.timeline.Less(NTP, head) && !q.timeline.Less(head, NTP) {
+				item.store.pop()
+				item.lastPopped, item.hasLastPopped = head, true
+				results[item.key] = value
+			}
+			item.mu.Unlock()
+			q.reindex(item)
+		}
+		if remove && len(results) > 0 {
+			q.advanceOutput(NTP)
+		}
+	}
+	clear(q.scratch)
+	q.indexMu.Unlock()
+
+	for _, e := range pending {
+		q.report(e.key, e.items)
+	}
+
+	if len(results) == 0 {
+		q.maps.Put(results)
+		var zero P
+		return nil, zero, false
+	}
+	return results, NTP, true
+}
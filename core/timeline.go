@@ -0,0 +1,62 @@
+package core
+
+import "time"
+
+// Timeline 描述时间戳类型P的排序方式与距离度量，是TemporalQueue对时间戳类型的全部要求。
+//
+// 队列中所有与时长相关的配置（采样窗口、过期时间等）都以time.Duration表示，
+// 并通过Distance换算到时间戳所在的单位，因此同一套配置对任意时间戳类型都有效。
+type Timeline[P any] struct {
+	// Less 报告时间戳a是否早于时间戳b。
+	Less func(a, b P) bool
+	// Distance 返回从时间戳from到时间戳to经过的时长，to早于from时为负。
+	Distance func(from, to P) time.Duration
+	// Now 返回当前时刻对应的时间戳。队列不会弹出晚于Now的任务；为nil时不做此限制。
+	Now func() P
+}
+
+// UnixNanoTimeline 返回以int64表示的Unix纳秒时间戳的时间线，这是AsynchronousTemporalQueue使用的时间线。
+func UnixNanoTimeline() Timeline[int64] {
+	return Timeline[int64]{
+		Less: func(a, b int64) bool {
+			return a < b
+		},
+		Distance: func(from, to int64) time.Duration {
+			return time.Duration(to - from)
+		},
+		Now: func() int64 {
+			return time.Now().UnixNano()
+		},
+	}
+}
+
+// TimeTimeline 返回以time.Time表示的时间戳的时间线。
+func TimeTimeline() Timeline[time.Time] {
+	return Timeline[time.Time]{
+		Less: func(a, b time.Time) bool {
+			return a.Before(b)
+		},
+		Distance: func(from, to time.Time) time.Duration {
+			return to.Sub(from)
+		},
+		Now: time.Now,
+	}
+}
+
+// PTSTimeline 返回以uint64表示的、时间基为num/den秒的显示时间戳（PTS）的时间线。
+//
+// 帧序号可以视为时间基为1/帧率的PTS，例如25fps的帧序号使用PTSTimeline(1, 25)，29.97fps使用PTSTimeline(1001, 30000)。
+// PTS与系统时间没有对应关系，因此返回的时间线不限制未来时间戳的出队。
+func PTSTimeline(num, den uint64) Timeline[uint64] {
+	if num == 0 || den == 0 {
+		panic("core: PTS timebase must be positive")
+	}
+	return Timeline[uint64]{
+		Less: func(a, b uint64) bool {
+			return a < b
+		},
+		Distance: func(from, to uint64) time.Duration {
+			return time.Duration(ticksToNanos(int64(to-from)*int64(num), den))
+		},
+	}
+}
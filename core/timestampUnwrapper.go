@@ -0,0 +1,184 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMaxTimestampJump 是TimestampUnwrapper判定时间戳跳变的默认阈值。
+const DefaultMaxTimestampJump = 5 * time.Second
+
+// TimestampEventKind 描述TimestampUnwrapper上报的事件类型。
+type TimestampEventKind int
+
+const (
+	// TimestampWrap 表示计数器发生了一次回绕。
+	TimestampWrap TimestampEventKind = iota
+	// TimestampJump 表示相邻两次时间戳的差值超过了跳变阈值。
+	TimestampJump
+)
+
+// TimestampEvent 是TimestampUnwrapper通过事件钩子上报的事件。
+//
+// Raw和Previous为回绕计数器的原始值，Delta为两者换算后的时间差（可能为负），
+// NTP为Raw展开后的时间戳（单位：纳秒）。
+type TimestampEvent struct {
+	Kind     TimestampEventKind
+	Raw      uint64
+	Previous uint64
+	Delta    time.Duration
+	NTP      int64
+}
+
+// TimestampUnwrapper 将会回绕的定长计数器时间戳（如32位RTP时间戳）展开为单调的64位纳秒时间戳。
+//
+// 展开器以第一个样本为锚点：第一个样本映射到基准时间（默认为第一个样本到达时的系统时间，可通过SetBase指定），
+// 后续样本按照时钟频率换算为相对锚点的偏移量。相邻样本的差值按半个计数范围判定方向，
+// 因此轻微乱序的样本不会被误判为回绕。
+//
+// TimestampUnwrapper的所有操作都是并发安全的。
+type TimestampUnwrapper struct {
+	mu        sync.Mutex
+	bits      uint
+	clockRate uint64
+	maxJump   time.Duration
+	base      int64
+	hasBase   bool
+	started   bool
+	first     int64
+	last      int64
+	onEvent   func(TimestampEvent)
+}
+
+// NewTimestampUnwrapper 创建一个新的时间戳展开器。
+//
+// 参数：
+//
+//	bits uint: 计数器的位宽，取值范围为1到63（RTP时间戳为32）。
+//	clockRate uint64: 计数器的时钟频率（单位：Hz，如H264视频为90000）。
+func NewTimestampUnwrapper(bits uint, clockRate uint64) *TimestampUnwrapper {
+	if bits == 0 || bits > 63 {
+		panic("core: timestamp unwrapper bits must be in [1, 63]")
+	}
+	if clockRate == 0 {
+		panic("core: timestamp unwrapper clock rate must be positive")
+	}
+	return &TimestampUnwrapper{
+		bits:      bits,
+		clockRate: clockRate,
+		maxJump:   DefaultMaxTimestampJump,
+	}
+}
+
+// SetBase 指定第一个样本对应的纳秒时间戳。须在第一次调用Unwrap之前设置才会生效。
+func (u *TimestampUnwrapper) SetBase(NTP int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.base = NTP
+	u.hasBase = true
+}
+
+// SetMaxJump 设置跳变阈值，d为0时不再上报跳变事件。
+func (u *TimestampUnwrapper) SetMaxJump(d time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.maxJump = d
+}
+
+// OnEvent 设置回绕与跳变事件的钩子。钩子在Unwrap的调用方goroutine中执行。
+func (u *TimestampUnwrapper) OnEvent(fn func(TimestampEvent)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.onEvent = fn
+}
+
+// Reset 丢弃已有的展开状态，下一个样本将重新作为锚点。通过SetBase指定的基准时间会被保留。
+func (u *TimestampUnwrapper) Reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.started = false
+	u.first = 0
+	u.last = 0
+}
+
+// Unwrap 将原始计数器值raw展开为单调的纳秒时间戳。
+//
+// 若raw相对上一个最新样本向前越过了计数范围的边界，则上报TimestampWrap事件；
+// 若两者换算后的时间差超过跳变阈值，则上报TimestampJump事件。
+// 早于最新样本的乱序样本会被正确展开，但不会推进展开器的状态。
+func (u *TimestampUnwrapper) Unwrap(raw uint64) int64 {
+	u.mu.Lock()
+
+	mask := uint64(1)<<u.bits - 1
+	raw &= mask
+
+	if !u.started {
+		if !u.hasBase {
+			u.base = time.Now().UnixNano()
+		}
+		u.started = true
+		u.first = int64(raw)
+		u.last = u.first
+		NTP := u.base
+		u.mu.Unlock()
+		return NTP
+	}
+
+	prev := uint64(u.last) & mask
+	delta := int64((raw - prev) & mask)
+	if delta >= int64(1)<<(u.bits-1) {
+		delta -= int64(1) << u.bits
+	}
+	extended := u.last + delta
+	NTP := u.base + ticksToNanos(extended-u.first, u.clockRate)
+
+	var events []TimestampEvent
+	if u.onEvent != nil {
+		if delta > 0 && extended>>u.bits != u.last>>u.bits {
+			events = append(events, TimestampEvent{Kind: TimestampWrap, Raw: raw, Previous: prev, Delta: time.Duration(ticksToNanos(delta, u.clockRate)), NTP: NTP})
+		}
+		if d := time.Duration(ticksToNanos(delta, u.clockRate)); u.maxJump > 0 && (d > u.maxJump || d < -u.maxJump) {
+			events = append(events, TimestampEvent{Kind: TimestampJump, Raw: raw, Previous: prev, Delta: d, NTP: NTP})
+		}
+	}
+	if delta > 0 {
+		u.last = extended
+	}
+	onEvent := u.onEvent
+	u.mu.Unlock()
+
+	for _, event := range events {
+		onEvent(event)
+	}
+	return NTP
+}
+
+// (q *AsynchronousTemporalQueue) SetUnwrapper 为异步时间队列（q）中与给定键（key）关联的通道绑定时间戳展开器（u）。
+//
+// 绑定后可通过PushRaw直接推入回绕计数器形式的原始时间戳。u为nil时解除绑定。
+// 若给定键对应的通道不存在，返回false。
+func (q *AsynchronousTemporalQueue) SetUnwrapper(key string, u *TimestampUnwrapper) bool {
+	if v, ok := q.channelMap.Load(key); ok {
+		v.(*asynchronousTemporalQueueItem[int64]).unwrapper.Store(u)
+		return true
+	}
+	return false
+}
+
+// (q *AsynchronousTemporalQueue) PushRaw 使用通道绑定的时间戳展开器将原始计数器值（raw）展开为纳秒时间戳后，向通道添加任务（value）。
+//
+// 注意：若给定键对应的通道不存在、已关闭或未绑定展开器，此函数将不会添加任务。
+func (q *AsynchronousTemporalQueue) PushRaw(key string, value any, raw uint64) {
+	if v, ok := q.channelMap.Load(key); ok {
+		item := v.(*asynchronousTemporalQueueItem[int64])
+		if u := item.unwrapper.Load(); u != nil && item.loadState() == ChannelOpen {
+			q.Push(key, value, u.Unwrap(raw))
+		}
+	}
+}
+
+// ticksToNanos 将时钟频率为clockRate的计数值换算为纳秒，避免中间结果溢出。
+func ticksToNanos(ticks int64, clockRate uint64) int64 {
+	rate := int64(clockRate)
+	return ticks/rate*int64(time.Second) + ticks%rate*int64(time.Second)/rate
+}
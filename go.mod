@@ -1,6 +1,6 @@
 module github.com/murInJ/Asynchronous-Temporal-Queue
 
-go 1.22.1
+go 1.23
 
 require (
 	github.com/aler9/gortsplib v1.0.1
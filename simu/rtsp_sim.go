@@ -20,10 +20,12 @@ import (
 
 func run_rtsp(url string, index int, wg sync.WaitGroup, q *core.AsynchronousTemporalQueue) {
 	srcName := fmt.Sprintf("rtsp src%d", index)
+	// 断线重连时可立即重新创建同名通道，旧通道中的帧会先于新通道的帧输出
 	q.CreateChannel(srcName)
+	defer q.CloseChannel(srcName)
 	sw := sync.Map{}
 	sw.Store(srcName, 0.5)
-	q.StartSample(25, sw)
+	q.StartSample(25, &sw)
 	c := gortsplib.Client{}
 
 	// parse URL
@@ -133,7 +135,20 @@ func run_rtsp(url string, index int, wg sync.WaitGroup, q *core.AsynchronousTemp
 
 func handler(q *core.AsynchronousTemporalQueue) {
 	windows := make(map[string]*gocv.Window)
+	events, cancel := q.Subscribe()
+	defer cancel()
 	for {
+		select {
+		case e := <-events:
+			// 通道移除后关闭其窗口，已被重新创建的通道保留窗口
+			srcName := fmt.Sprintf("out %s", e.Key)
+			_, live := q.ChannelState(e.Key)
+			if window, ok := windows[srcName]; ok && e.Kind == core.EventChannelRemoved && !live {
+				window.Close()
+				delete(windows, srcName)
+			}
+		default:
+		}
 		v, _, ok := q.Pop()
 		if ok {
 			// fmt.Println(ntp)
@@ -142,7 +157,6 @@ func handler(q *core.AsynchronousTemporalQueue) {
 				if _, ok := windows[srcName]; !ok {
 					windows[srcName] = gocv.NewWindow(srcName)
 					windows[srcName].ResizeWindow(512, 512)
-					defer windows[srcName].Close()
 				}
 
 				img := *value.(*image.Image)
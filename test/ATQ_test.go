@@ -1,6 +1,7 @@
 package test
 
 import (
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -54,7 +55,7 @@ func TestAsynchronousTemporalQueue(t *testing.T) {
 	queue.CloseChannel("channel1")
 
 	// Test StartSample and CloseSample
-	queue.StartSample(60, sync.Map{})
+	queue.StartSample(60, &sync.Map{})
 	queue.CloseSample()
 }
 
@@ -66,7 +67,7 @@ func TestAsynchronousTemporalQueueWithSampling(t *testing.T) {
 
 	sampleWeights := sync.Map{}
 	sampleWeights.Store("channel1", 1.0)
-	queue.StartSample(60, sampleWeights)
+	queue.StartSample(60, &sampleWeights)
 
 	// Test Push and Pop
 	wg := sync.WaitGroup{}
@@ -97,6 +98,46 @@ func TestAsynchronousTemporalQueueWithSampling(t *testing.T) {
 	queue.CloseSample()
 }
 
+func TestPopOrderAcrossChannels(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+
+	wg := sync.WaitGroup{}
+	for c := 0; c < 50; c++ {
+		key := fmt.Sprintf("channel_%d", c)
+		queue.CreateChannel(key)
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := 199; i >= 0; i-- {
+				queue.Push(key, c, uint64(i*50+c%7))
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	count := 0
+	prev := uint64(0)
+	for !queue.Empty() {
+		values, frame, ok := queue.Pop()
+		if !ok {
+			t.Fatal("Pop operation failed.")
+		}
+		if frame < prev {
+			t.Fatalf("Pop returned frame %d after %d", frame, prev)
+		}
+		for key, value := range values {
+			if key != fmt.Sprintf("channel_%d", value) || uint64(value.(int)%7) != frame%50 {
+				t.Fatalf("frame %d carries %s=%v", frame, key, value)
+			}
+		}
+		prev = frame
+		count += len(values)
+	}
+	if count != 50*200 {
+		t.Errorf("popped %d items, want %d", count, 50*200)
+	}
+}
+
 // BenchmarkCreateChannel 测试创建通道的性能
 func BenchmarkCreateChannel(b *testing.B) {
 	// 并发数量，可根据需要调整
@@ -128,25 +169,27 @@ func BenchmarkCloseChannel(b *testing.B) {
 	// 并发数量，可根据需要调整
 	concurrency := 100
 
-	// 创建通道
-	for i := 0; i < concurrency; i++ {
-		key := "channel_" + time.Now().String()
-		queue.CreateChannel(key)
+	// 预先创建b.N个各有一个任务的通道，使关闭的通道进入Closing状态而不是立即被移除
+	keys := make([]string, b.N)
+	ntp := time.Now().UnixNano() - int64(time.Hour)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("channel_%d", i)
+		queue.CreateChannel(keys[i])
+		queue.Push(keys[i], "test_value", ntp+int64(i))
 	}
 
 	b.ResetTimer()
 
-	// 启动并发关闭通道任务
+	// 启动并发关闭通道任务，共关闭b.N个通道
 	var wg sync.WaitGroup
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		go func() {
+		go func(i int) {
 			defer wg.Done()
-			for j := 0; j < b.N; j++ {
-				key := "channel_" + time.Now().String()
-				queue.CloseChannel(key)
+			for j := i; j < b.N; j += concurrency {
+				queue.CloseChannel(keys[j])
 			}
-		}()
+		}(i)
 	}
 	wg.Wait()
 }
@@ -159,19 +202,25 @@ func BenchmarkPush(b *testing.B) {
 	// 并发数量，可根据需要调整
 	concurrency := 100
 
+	// 每个并发任务推送到各自的通道
+	keys := make([]string, concurrency)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("channel_%d", i)
+		queue.CreateChannel(keys[i])
+	}
+
 	b.ResetTimer()
 
-	// 启动并发推送任务
+	// 启动并发推送任务，共推送b.N个任务
 	var wg sync.WaitGroup
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		go func() {
+		go func(key string, n int) {
 			defer wg.Done()
-			for j := 0; j < b.N; j++ {
-				key := "channel_" + time.Now().String()
+			for j := 0; j < n; j++ {
 				queue.Push(key, "test_value", time.Now().UnixNano())
 			}
-		}()
+		}(keys[i], share(b.N, concurrency, i))
 	}
 	wg.Wait()
 }
@@ -184,22 +233,44 @@ func BenchmarkPop(b *testing.B) {
 	// 并发数量，可根据需要调整
 	concurrency := 100
 
+	// 预先向各通道推送共b.N个时间戳互不相同的任务
+	ntp := time.Now().UnixNano() - int64(time.Hour)
+	for i := 0; i < concurrency; i++ {
+		key := fmt.Sprintf("channel_%d", i)
+		queue.CreateChannel(key)
+		for j := 0; j < share(b.N, concurrency, i); j++ {
+			queue.Push(key, "test_value", ntp)
+			ntp++
+		}
+	}
+
 	b.ResetTimer()
 
-	// 启动并发推送任务
+	// 启动并发弹出任务，共弹出b.N个任务
 	var wg sync.WaitGroup
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		go func() {
+		go func(n int) {
 			defer wg.Done()
-			for j := 0; j < b.N; j++ {
-				queue.Pop()
+			for j := 0; j < n; j++ {
+				if _, _, ok := queue.Pop(); !ok {
+					b.Error("Pop operation failed.")
+					return
+				}
 			}
-		}()
+		}(share(b.N, concurrency, i))
 	}
 	wg.Wait()
 }
 
+// share 返回将n个操作平均分给parts个并发任务时第i个任务分到的数量。
+func share(n, parts, i int) int {
+	if i < n%parts {
+		return n/parts + 1
+	}
+	return n / parts
+}
+
 // BenchmarkHead 测试获取队首任务的性能
 func BenchmarkHead(b *testing.B) {
 	// 创建异步时间队列实例
@@ -208,18 +279,61 @@ func BenchmarkHead(b *testing.B) {
 	// 并发数量，可根据需要调整
 	concurrency := 100
 
+	// 预先向各通道推送时间戳相同的任务，使每次Head都合并所有通道的队首
+	ntp := time.Now().UnixNano() - int64(time.Hour)
+	for i := 0; i < concurrency; i++ {
+		key := fmt.Sprintf("channel_%d", i)
+		queue.CreateChannel(key)
+		for j := 0; j < 10; j++ {
+			queue.Push(key, "test_value", ntp+int64(j))
+		}
+	}
+
 	b.ResetTimer()
 
-	// 启动并发推送任务
+	// 启动并发获取队首任务，共调用b.N次Head
 	var wg sync.WaitGroup
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		go func() {
+		go func(n int) {
 			defer wg.Done()
-			for j := 0; j < b.N; j++ {
-				queue.Head()
+			for j := 0; j < n; j++ {
+				values, _, ok := queue.Head()
+				if !ok || len(values) != concurrency {
+					b.Error("Head operation failed.")
+					return
+				}
+				queue.Release(values)
 			}
-		}()
+		}(share(b.N, concurrency, i))
 	}
 	wg.Wait()
 }
+
+// BenchmarkPopChannels 测试通道数从10增长到10000时弹出任务的性能
+func BenchmarkPopChannels(b *testing.B) {
+	for _, channels := range []int{10, 100, 1000, 10000} {
+		b.Run(fmt.Sprintf("channels=%d", channels), func(b *testing.B) {
+			queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+			keys := make([]string, channels)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("channel_%d", i)
+				queue.CreateChannel(keys[i])
+				queue.Push(keys[i], i, uint64(i))
+			}
+			next := uint64(channels)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				values, _, ok := queue.Pop()
+				if !ok {
+					b.Fatal("Pop operation failed.")
+				}
+				for key := range values {
+					queue.Push(key, i, next)
+					next++
+				}
+			}
+		})
+	}
+}
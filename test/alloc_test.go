@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func newSteadyStateQueue() (*core.AsynchronousTemporalQueue, func()) {
+	queue := core.NewAsynchronousTemporalQueue()
+	queue.CreateChannel("camera")
+	queue.CreateChannel("imu", core.WithOrdering(core.OrderingHeap))
+
+	var value any = &struct{}{}
+	NTP := int64(1)
+	step := func() {
+		queue.Push("camera", value, NTP)
+		queue.Push("imu", value, NTP)
+		NTP++
+		values, _, _ := queue.Pop()
+		queue.Release(values)
+	}
+	// 预热，使缓冲区与映射池达到稳定容量
+	for i := 0; i < 1024; i++ {
+		queue.Push("camera", value, NTP)
+		queue.Push("imu", value, NTP)
+		NTP++
+	}
+	for i := 0; i < 1024; i++ {
+		step()
+	}
+	return queue, step
+}
+
+func TestSteadyStateZeroAllocs(t *testing.T) {
+	_, step := newSteadyStateQueue()
+	if allocs := testing.AllocsPerRun(1000, step); allocs != 0 {
+		t.Errorf("steady-state Push/Pop made %v allocations per run, want 0", allocs)
+	}
+}
+
+// BenchmarkSteadyStatePushPop 测试稳定状态下推入与弹出任务的性能与内存分配
+func BenchmarkSteadyStatePushPop(b *testing.B) {
+	_, step := newSteadyStateQueue()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		step()
+	}
+	b.StopTimer()
+	if allocs := testing.AllocsPerRun(100, step); allocs != 0 {
+		b.Errorf("steady-state Push/Pop made %v allocations per run, want 0", allocs)
+	}
+}
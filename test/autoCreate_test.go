@@ -0,0 +1,115 @@
+package test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestAutoCreate(t *testing.T) {
+	// 未开启自动创建时，推入不存在的通道被丢弃
+	plain := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	plain.Push("logs/a", "x", 1)
+	if plain.Len() != 0 {
+		t.Fatal("Push created a channel without WithAutoCreate")
+	}
+
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000), core.WithAutoCreate(core.WithStorage(core.StorageSkipList)))
+	var created sync.Map
+	queue.OnLifecycleEvent(func(e core.LifecycleEvent) {
+		if e.Kind == core.EventChannelCreated {
+			n, _ := created.LoadOrStore(e.Key, new(int))
+			*n.(*int)++
+		}
+	})
+
+	// 多个生产者并发首次推入同一个键，只创建一个通道
+	wg := sync.WaitGroup{}
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			queue.Push("logs/a", p, uint64(p))
+		}(p)
+	}
+	wg.Wait()
+	if n, _ := created.Load("logs/a"); *n.(*int) != 1 {
+		t.Errorf("logs/a created %d times", *n.(*int))
+	}
+	if n, ok := queue.ChannelLen("logs/a"); !ok || n != 8 {
+		t.Errorf("ChannelLen = %d, %v", n, ok)
+	}
+	// 自动创建的通道应用模板配置
+	if _, ok := queue.PushHandle("logs/b", "y", 1); ok {
+		t.Error("template storage was not applied")
+	}
+	queue.PushBatch("logs/c", []core.Item[uint64]{{Value: "z", NTP: 2}})
+	if keys := len(queue.Channels()); keys != 3 {
+		t.Errorf("%d channels, want 3", keys)
+	}
+
+	// 已关闭的通道不会被自动重新创建
+	queue.CloseChannel("logs/a")
+	queue.Push("logs/a", "late", 9)
+	if n, _ := queue.ChannelLen("logs/a"); n != 8 {
+		t.Errorf("Push reopened a closed channel: ChannelLen = %d", n)
+	}
+}
+
+func TestAutoCreateFuncAndAllowlist(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000),
+		core.WithAutoCreateFunc(func(key string) []core.ChannelOption {
+			if strings.HasPrefix(key, "hot/") {
+				return []core.ChannelOption{core.WithStorage(core.StorageSkipList)}
+			}
+			return nil
+		}),
+		core.WithAutoCreateAllowlist("hot/*", "metrics"),
+	)
+
+	if _, ok := queue.PushHandle("metrics", 1, 1); !ok {
+		t.Error("PushHandle failed on an auto-created default channel")
+	}
+	if _, ok := queue.PushHandle("hot/cam0", 1, 1); ok {
+		t.Error("factory storage was not applied to hot/cam0")
+	}
+	queue.Push("hot/cam0", 2, 2)
+
+	// 不在允许列表中的键（包括拼写错误）不会被创建
+	for _, key := range []string{"metirc", "hot/cam0/sub", "cold/cam0"} {
+		queue.Push(key, 0, 0)
+		if _, ok := queue.ChannelState(key); ok {
+			t.Errorf("%s was auto-created", key)
+		}
+	}
+	if queue.Len() != 2 {
+		t.Errorf("Len = %d, want 2", queue.Len())
+	}
+	// 显式创建不受允许列表限制
+	queue.CreateChannel("cold/cam0")
+	queue.Push("cold/cam0", 3, 3)
+	if n, _ := queue.ChannelLen("cold/cam0"); n != 1 {
+		t.Errorf("ChannelLen(cold/cam0) = %d", n)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("malformed allowlist pattern did not panic")
+		}
+	}()
+	core.NewTemporalQueue(core.PTSTimeline(1, 1000), core.WithAutoCreateAllowlist("hot/["))
+}
+
+func TestAutoCreateEmptyAllowlist(t *testing.T) {
+	// 不带模式的允许列表拒绝所有键，而不是等同于未设置允许列表
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000), core.WithAutoCreate(), core.WithAutoCreateAllowlist())
+	queue.Push("cam0", 1, 1)
+	if _, ok := queue.PushHandle("cam1", 1, 1); ok {
+		t.Error("PushHandle auto-created cam1")
+	}
+	if channels := queue.Channels(); len(channels) != 0 || queue.Len() != 0 {
+		t.Errorf("Channels = %+v, Len = %d", channels, queue.Len())
+	}
+}
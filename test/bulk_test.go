@@ -0,0 +1,126 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func newBulkQueue() *core.TemporalQueue[uint64] {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 30))
+	queue.CreateChannel("left")
+	queue.CreateChannel("right")
+	for frame := uint64(0); frame < 10; frame++ {
+		queue.Push("left", frame, frame)
+		if frame%2 == 0 {
+			queue.Push("right", frame, frame)
+		}
+	}
+	return queue
+}
+
+func TestPopUntil(t *testing.T) {
+	queue := newBulkQueue()
+
+	frames := queue.PopUntil(4)
+	if len(frames) != 5 {
+		t.Fatalf("PopUntil returned %d frames, want 5", len(frames))
+	}
+	for i, frame := range frames {
+		if frame.NTP != uint64(i) {
+			t.Errorf("frame %d has timestamp %d", i, frame.NTP)
+		}
+		if want := 1 + (i+1)%2; len(frame.Values) != want {
+			t.Errorf("frame %d has %d values, want %d", i, len(frame.Values), want)
+		}
+	}
+
+	values, frame, ok := queue.Pop()
+	if !ok || frame != 5 || len(values) != 1 {
+		t.Errorf("Pop after PopUntil returned %v at %d", values, frame)
+	}
+}
+
+func TestPopNAndDrain(t *testing.T) {
+	queue := newBulkQueue()
+
+	frames := queue.PopN(3)
+	if len(frames) != 3 || frames[2].NTP != 2 {
+		t.Fatalf("PopN returned %+v", frames)
+	}
+
+	frames = queue.Drain()
+	if len(frames) != 7 || frames[0].NTP != 3 || frames[6].NTP != 9 {
+		t.Fatalf("Drain returned %+v", frames)
+	}
+	if !queue.Empty() || len(queue.Drain()) != 0 {
+		t.Error("queue should be empty after Drain")
+	}
+}
+
+func TestPushBatchAndFrame(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 200))
+	queue.CreateChannel("imu")
+	queue.CreateChannel("camera")
+
+	batch := make([]core.Item[uint64], 0, 200)
+	for i := 199; i >= 0; i-- {
+		batch = append(batch, core.Item[uint64]{Value: i, NTP: uint64(i)})
+	}
+	queue.PushBatch("imu", batch)
+	queue.PushBatch("missing", batch)
+	queue.PushFrame(map[string]core.Item[uint64]{
+		"imu":    {Value: "late", NTP: 400},
+		"camera": {Value: "frame", NTP: 0},
+	})
+
+	select {
+	case <-queue.Ready():
+	default:
+		t.Error("Ready should be signalled after a push.")
+	}
+
+	frames := queue.Drain()
+	if len(frames) != 201 {
+		t.Fatalf("Drain returned %d frames, want 201", len(frames))
+	}
+	if len(frames[0].Values) != 2 || frames[0].Values["camera"] != "frame" {
+		t.Errorf("first frame is %v", frames[0].Values)
+	}
+	for i, frame := range frames[:200] {
+		if frame.Values["imu"] != i {
+			t.Fatalf("frame %d carries %v", i, frame.Values["imu"])
+		}
+	}
+}
+
+func TestChannelPriority(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 30))
+	queue.CreateChannel("a")
+	queue.CreateChannel("b", core.WithChannelPriority(5))
+	queue.CreateChannel("c", core.WithChannelPriority(5))
+	queue.CreateChannel("d", core.WithChannelPriority(-1))
+	for _, key := range []string{"d", "c", "b", "a"} {
+		queue.Push(key, key, 1)
+		queue.Push(key, key, 2)
+	}
+	queue.Push("a", "a", 3)
+	queue.Push("d", "d", 3)
+
+	// 时间戳相同的通道按优先级从高到低排列，优先级相同时按通道键排列
+	values, _, _ := queue.Pop()
+	if keys := fmt.Sprint(queue.OrderedKeys(values)); keys != "[b c a d]" {
+		t.Errorf("OrderedKeys = %s", keys)
+	}
+	var got []string
+	for _, frame := range queue.Drain() {
+		if len(frame.Keys) != len(frame.Values) {
+			t.Fatalf("frame at %d has Keys %v for %v", frame.NTP, frame.Keys, frame.Values)
+		}
+		got = append(got, fmt.Sprint(frame.Keys))
+	}
+	if fmt.Sprint(got) != "[[b c a d] [a d]]" {
+		t.Errorf("Drain keys = %v", got)
+	}
+}
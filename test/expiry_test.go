@@ -0,0 +1,110 @@
+package test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestTTLFromNewest(t *testing.T) {
+	// 10fps的帧序号，过期时间为3帧
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 10), core.WithTTL(300*time.Millisecond, core.TTLFromNewest))
+	queue.CreateChannel("camera")
+	queue.CreateChannel("imu", core.WithChannelTTL(0, core.TTLFromNewest))
+
+	var evicted []any
+	queue.OnEvict(func(key string, value any, frame uint64) {
+		evicted = append(evicted, value)
+	})
+
+	for frame := uint64(0); frame < 10; frame++ {
+		queue.Push("camera", frame, frame)
+	}
+	queue.Push("imu", "imu0", 0)
+
+	values, frame, ok := queue.Pop()
+	if !ok || frame != 0 || len(values) != 1 || values["imu"] != "imu0" {
+		t.Errorf("Pop returned %v at frame %d", values, frame)
+	}
+	values, frame, ok = queue.Pop()
+	if !ok || frame != 6 || values["camera"] != uint64(6) {
+		t.Errorf("Pop returned %v at frame %d", values, frame)
+	}
+	if len(evicted) != 6 || queue.Evicted() != 6 || queue.ChannelEvicted("camera") != 6 || queue.ChannelEvicted("imu") != 0 {
+		t.Errorf("unexpected eviction: %v, total %d", evicted, queue.Evicted())
+	}
+}
+
+func TestTTLSweep(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue(core.WithTTL(time.Second, core.TTLFromNow))
+	queue.CreateChannel("channel1")
+
+	var mu sync.Mutex
+	var evicted []any
+	queue.OnEvict(func(key string, value any, NTP int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		evicted = append(evicted, value)
+	})
+
+	now := time.Now()
+	queue.Push("channel1", "stale", now.Add(-time.Minute).UnixNano())
+	queue.Push("channel1", "fresh", now.UnixNano())
+
+	queue.StartSweep(5 * time.Millisecond)
+	defer queue.CloseSweep()
+
+	deadline := time.Now().Add(time.Second)
+	for queue.ChannelEvicted("channel1") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) != 1 || evicted[0] != "stale" {
+		t.Errorf("sweeper evicted %v", evicted)
+	}
+}
+
+func TestSweepNonPositiveInterval(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue(core.WithTTL(time.Second, core.TTLFromNow))
+	queue.CreateChannel("channel1")
+	queue.Push("channel1", "stale", time.Now().Add(-time.Minute).UnixNano())
+
+	// interval不为正时不启动后台清理，也不会panic
+	queue.StartSweep(0)
+	queue.StartSweep(-time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if queue.ChannelEvicted("channel1") != 0 {
+		t.Error("a sweeper ran with a non-positive interval")
+	}
+	queue.CloseSweep()
+}
+
+func TestConcurrentStartSweep(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue(core.WithTTL(time.Second, core.TTLFromNow))
+	before := runtime.NumGoroutine()
+
+	// 并发启动后台清理后只应保留一个清理goroutine，CloseSweep之后全部退出
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queue.StartSweep(time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	queue.CloseSweep()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d sweeper goroutines leaked", n-before)
+	}
+}
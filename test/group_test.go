@@ -0,0 +1,128 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestChannelGroups(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	var evicted []string
+	queue.OnEvict(func(key string, value any, NTP uint64) {
+		evicted = append(evicted, key)
+	})
+
+	// 两套设备，每套4个相机与1个IMU
+	for r := 1; r <= 2; r++ {
+		rig := queue.Group(fmt.Sprintf("rig%d", r))
+		rig.Group("cams").Configure(core.WithChannelTTL(10*time.Millisecond, core.TTLFromNewest))
+		for c := 0; c < 4; c++ {
+			rig.Group("cams").CreateChannel(fmt.Sprintf("cam%d", c))
+		}
+		rig.CreateChannel("imu")
+	}
+	queue.CreateChannel("global")
+
+	rig1, rig2 := queue.Group("rig1"), queue.Group("rig2")
+	if keys := rig1.Keys(); len(keys) != 5 || keys[0] != "rig1/cams/cam0" || keys[4] != "rig1/imu" {
+		t.Fatalf("rig1 keys = %v", keys)
+	}
+	if keys := queue.Group("rig1/cams").Keys(); len(keys) != 4 {
+		t.Fatalf("rig1/cams keys = %v", keys)
+	}
+
+	for c := 0; c < 4; c++ {
+		rig1.Push(fmt.Sprintf("cams/cam%d", c), c, 20)
+		rig2.Push(fmt.Sprintf("cams/cam%d", c), c, 10)
+	}
+	rig1.Push("imu", "imu", 20)
+	rig2.Push("imu", "imu", 30)
+	queue.Push("global", "g", 0)
+
+	// 分组只在组内合并，不受更早的其他通道影响
+	values, frame, ok := rig1.Pop()
+	if !ok || frame != 20 || len(values) != 5 {
+		t.Fatalf("rig1.Pop = %v at %d", values, frame)
+	}
+	queue.Release(values)
+	// 分组配置作用于组内新建的通道：rig2的相机任务相对最新的任务（30）已过期，rig1的相机任务（20）未过期
+	if values, frame, _ := rig2.Head(); frame != 30 || len(values) != 1 {
+		t.Errorf("rig2.Head = %v at %d, want the IMU at 30", values, frame)
+	}
+	stats := rig2.Stats()
+	if stats.Channels != 5 || stats.Open != 5 || stats.Len != 1 || stats.Evicted != 4 || stats.Head != 30 {
+		t.Errorf("rig2 stats = %+v", stats)
+	}
+	if len(evicted) != 4 || evicted[0][:5] != "rig2/" {
+		t.Errorf("evicted %v", evicted)
+	}
+
+	// 关闭分组后，空通道立即移除，IMU中剩余的任务仍可弹出
+	rig2.Close()
+	if stats := rig2.Stats(); stats.Channels != 1 || stats.Closing != 1 {
+		t.Errorf("rig2 stats after Close = %+v", stats)
+	}
+	if values, frame, _ := rig2.Pop(); frame != 30 || values["rig2/imu"] != "imu" {
+		t.Errorf("rig2.Pop = %v at %d, want the IMU at 30", values, frame)
+	}
+	if n := len(rig2.Keys()); n != 0 {
+		t.Errorf("rig2 still has %d channels once drained", n)
+	}
+	if values, _, _ := queue.Pop(); values["global"] != "g" {
+		t.Errorf("global Pop returned %v", values)
+	}
+}
+
+func TestGroupMembershipUnderChurn(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	group := queue.Group("rig")
+
+	// 一个goroutine反复创建并关闭同一个键的通道，另一个goroutine不断弹出使旧通道被移除，
+	// 分组成员应始终与通道是否存在一致
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if values, _, ok := queue.Pop(); ok {
+					queue.Release(values)
+				}
+			}
+		}
+	}()
+	for i := 0; i < 2000; i++ {
+		queue.CreateChannel("rig/cam0")
+		queue.Push("rig/cam0", i, uint64(i))
+		queue.CloseChannel("rig/cam0")
+		if i%2 == 0 {
+			queue.CreateChannel("rig/cam0")
+		}
+	}
+	close(stop)
+	<-done
+
+	_, live := queue.ChannelState("rig/cam0")
+	if keys := group.Keys(); live != (len(keys) == 1) {
+		t.Fatalf("channel live = %v but group keys = %v", live, keys)
+	}
+	queue.Drain()
+	queue.CloseChannel("rig/cam0")
+	queue.Drain()
+	if _, live := queue.ChannelState("rig/cam0"); live || len(group.Keys()) != 0 {
+		t.Errorf("channel live = %v, group keys = %v after draining", live, group.Keys())
+	}
+
+	// 没有通道的分组仍保留其配置
+	group.Configure(core.WithStorage(core.StorageSkipList))
+	group.CreateChannel("cam1")
+	if _, ok := queue.PushHandle("rig/cam1", 0, 0); ok {
+		t.Error("group configuration was lost")
+	}
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestChannelIntrospection(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	queue.CreateChannel("b")
+	queue.CreateChannel("a")
+
+	// 在推入任务之前开启并关闭采样，只为通道b设置权重，采样goroutine不会取走任务
+	weights := &sync.Map{}
+	weights.Store("b", 0.5)
+	queue.StartSample(30, weights)
+	queue.CloseSample()
+
+	queue.Push("a", 0, 5)
+	queue.Push("a", 1, 3)
+	queue.Push("b", 2, 4)
+	queue.Pop()
+	queue.CloseChannel("a")
+
+	infos := queue.Channels()
+	if len(infos) != 2 || infos[0].Key != "a" || infos[1].Key != "b" {
+		t.Fatalf("Channels returned %+v", infos)
+	}
+	a, b := infos[0], infos[1]
+	if a.State != core.ChannelClosing || a.Len != 1 || a.Head != 5 || !a.HasHead ||
+		a.LastPushed != 3 || !a.HasLastPushed || a.LastPopped != 3 || !a.HasLastPopped || a.HasWeight {
+		t.Errorf("channel a: %+v", a)
+	}
+	if b.State != core.ChannelOpen || b.Len != 1 || b.HasLastPopped || b.Weight != 0.5 || !b.HasWeight {
+		t.Errorf("channel b: %+v", b)
+	}
+
+	if queue.Len() != 2 {
+		t.Errorf("Len = %d, want 2", queue.Len())
+	}
+	if n, ok := queue.ChannelLen("b"); !ok || n != 1 {
+		t.Errorf("ChannelLen(b) = %d, %v", n, ok)
+	}
+	if _, ok := queue.ChannelLen("missing"); ok {
+		t.Error("ChannelLen should fail for a missing channel")
+	}
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestNTP64Conversion(t *testing.T) {
+	// 2024-04-01T00:00:00.5Z
+	if got := core.UnixNanoToNTP64(1711929600500000000); got != 0xE9B47780_80000000 {
+		t.Errorf("UnixNanoToNTP64 = %#x", got)
+	}
+	if got := core.NTP64ToUnixNano(0); got != -2208988800*int64(time.Second)+(1<<32)*int64(time.Second) {
+		t.Errorf("era 1 NTP64ToUnixNano(0) = %d", got)
+	}
+
+	for _, NTP := range []int64{
+		0,
+		1,
+		999999999,
+		time.Date(1970, 1, 1, 0, 0, 0, -1, time.UTC).UnixNano(),
+		time.Date(2024, 4, 1, 12, 34, 56, 123456789, time.UTC).UnixNano(),
+		time.Date(2036, 2, 7, 6, 28, 16, 1, time.UTC).UnixNano(),
+		time.Date(2100, 12, 31, 23, 59, 59, 999999999, time.UTC).UnixNano(),
+	} {
+		if got := core.NTP64ToUnixNano(core.UnixNanoToNTP64(NTP)); got != NTP {
+			t.Errorf("round trip of %d returned %d", NTP, got)
+		}
+	}
+}
+
+func TestPushNTP64(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue()
+	queue.CreateChannel("channel1")
+
+	ntp := core.UnixNanoToNTP64(time.Now().UnixNano())
+	queue.PushNTP64("channel1", "data", ntp)
+
+	values, got, ok := queue.PopNTP64()
+	if !ok || values["channel1"] != "data" {
+		t.Fatal("PopNTP64 operation failed.")
+	}
+	if got != ntp {
+		t.Errorf("PopNTP64 returned %#x, want %#x", got, ntp)
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestChannelOrdering(t *testing.T) {
+	for _, ordering := range []core.ChannelOrdering{core.OrderingAuto, core.OrderingMonotonic, core.OrderingHeap} {
+		queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+		queue.CreateChannel("channel1", core.WithOrdering(ordering))
+
+		// 先有序推入，再混入乱序任务，最后恢复有序推入
+		frames := make([]uint64, 0)
+		for i := uint64(0); i < 100; i++ {
+			frames = append(frames, i)
+		}
+		frames = append(frames, 50, 10, 99, 0)
+		for i := uint64(100); i < 300; i++ {
+			frames = append(frames, i)
+		}
+		for _, frame := range frames {
+			queue.Push("channel1", frame, frame)
+		}
+
+		prev := uint64(0)
+		count := 0
+		for !queue.Empty() {
+			values, frame, ok := queue.Pop()
+			if !ok || values["channel1"] != frame || frame < prev {
+				t.Fatalf("ordering %d: Pop returned %v at %d after %d", ordering, values, frame, prev)
+			}
+			prev = frame
+			count++
+		}
+		if count != len(frames) {
+			t.Errorf("ordering %d: popped %d items, want %d", ordering, count, len(frames))
+		}
+	}
+}
+
+// BenchmarkChannelOrdering 比较有序推入时环形缓冲区与堆的性能
+func BenchmarkChannelOrdering(b *testing.B) {
+	for _, ordering := range []core.ChannelOrdering{core.OrderingMonotonic, core.OrderingHeap} {
+		b.Run(fmt.Sprintf("ordering=%d", ordering), func(b *testing.B) {
+			queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+			queue.CreateChannel("channel1", core.WithOrdering(ordering))
+			for i := 0; i < 1024; i++ {
+				queue.Push("channel1", i, uint64(i))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				queue.Push("channel1", i, uint64(1024+i))
+				queue.Pop()
+			}
+		})
+	}
+}
+
+// pushSlightlyOutOfOrder 先向通道有序推入backlog个任务，再推入pushes个任务，其中每隔64次有序推入出现一个乱序任务。
+func pushSlightlyOutOfOrder(queue *core.TemporalQueue[uint64], backlog, pushes int) {
+	frame := uint64(0)
+	for ; frame < uint64(backlog); frame++ {
+		queue.Push("channel1", frame, frame)
+	}
+	for i := 0; i < pushes; i++ {
+		if i%65 == 64 {
+			queue.Push("channel1", frame-2, frame-2)
+			continue
+		}
+		queue.Push("channel1", frame, frame)
+		frame++
+	}
+}
+
+func TestSlightlyOutOfOrderChannel(t *testing.T) {
+	// 积压大量任务的通道中每隔monotonicStreak次有序推入就出现一个乱序任务，
+	// OrderingAuto不应在环形缓冲区与堆之间反复迁移整个积压：
+	// 有序的积压切换到环形缓冲区，第一个乱序任务回退到堆，此后应停留在堆中。
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	queue.CreateChannel("channel1", core.WithOrdering(core.OrderingAuto))
+	const backlog, pushes = 1 << 12, 1 << 12
+	pushSlightlyOutOfOrder(queue, backlog, pushes)
+
+	infos := queue.Channels()
+	if len(infos) != 1 || infos[0].Len != backlog+pushes {
+		t.Fatalf("Channels = %+v, want %d items", infos, backlog+pushes)
+	}
+	if infos[0].Migrations != 2 {
+		t.Errorf("Migrations = %d, want 2", infos[0].Migrations)
+	}
+
+	// 始终使用堆的通道不发生迁移
+	queue = core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	queue.CreateChannel("channel1", core.WithOrdering(core.OrderingHeap))
+	pushSlightlyOutOfOrder(queue, backlog, pushes)
+	if infos := queue.Channels(); infos[0].Migrations != 0 {
+		t.Errorf("OrderingHeap: Migrations = %d, want 0", infos[0].Migrations)
+	}
+}
+
+// BenchmarkSlightlyOutOfOrderChannel 比较时间戳轻微乱序时OrderingAuto与OrderingHeap的推入性能
+func BenchmarkSlightlyOutOfOrderChannel(b *testing.B) {
+	for _, ordering := range []core.ChannelOrdering{core.OrderingAuto, core.OrderingHeap} {
+		b.Run(fmt.Sprintf("ordering=%d", ordering), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+				queue.CreateChannel("channel1", core.WithOrdering(ordering))
+				pushSlightlyOutOfOrder(queue, 1<<15, 1<<15)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestPriorityQueueHandles(t *testing.T) {
+	pq := core.NewMinPriorityQueue[string, int]()
+	handles := make(map[string]core.Handle)
+	for i, value := range []string{"a", "b", "c", "d", "e"} {
+		handles[value] = pq.Push(value, i*10)
+	}
+
+	if !pq.Update(handles["e"], -1) {
+		t.Fatal("Update failed on a queued item.")
+	}
+	if value, priority, ok := pq.Remove(handles["c"]); !ok || value != "c" || priority != 20 {
+		t.Fatalf("Remove returned %v, %v, %v", value, priority, ok)
+	}
+	if pq.Contains(handles["c"]) {
+		t.Error("Contains reports a removed item.")
+	}
+	if _, _, ok := pq.Remove(handles["c"]); ok {
+		t.Error("Remove succeeded twice on the same handle.")
+	}
+
+	want := []string{"e", "a", "b", "d"}
+	for _, w := range want {
+		if !pq.Contains(handles[w]) {
+			t.Errorf("Contains(%s) = false", w)
+		}
+		value, _, ok := pq.Pop()
+		if !ok || value != w {
+			t.Errorf("Pop returned %v, want %v", value, w)
+		}
+		if pq.Contains(handles[w]) || pq.Update(handles[w], 0) {
+			t.Errorf("handle of popped item %s is still valid", w)
+		}
+	}
+
+	// 释放的槽位被复用后，旧句柄仍然无效
+	h := pq.Push("f", 0)
+	if pq.Contains(handles["d"]) || !pq.Contains(h) || pq.Contains(core.Handle{}) {
+		t.Error("stale handle refers to a reused slot")
+	}
+}
+
+func TestRetractAndRetime(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 30))
+	queue.CreateChannel("camera", core.WithOrdering(core.OrderingMonotonic))
+
+	queue.Push("camera", "f1", 1)
+	wrong, ok := queue.PushHandle("camera", "mis-stamped", 100)
+	if !ok {
+		t.Fatal("PushHandle operation failed.")
+	}
+	late, _ := queue.PushHandle("camera", "f0", 50)
+	queue.Push("camera", "f2", 2)
+
+	if value, ok := queue.Retract("camera", wrong); !ok || value != "mis-stamped" {
+		t.Errorf("Retract returned %v, %v", value, ok)
+	}
+	if !queue.Retime("camera", late, 0) {
+		t.Error("Retime operation failed.")
+	}
+
+	for _, want := range []string{"f0", "f1", "f2"} {
+		values, _, ok := queue.Pop()
+		if !ok || values["camera"] != want {
+			t.Errorf("Pop returned %v, want %s", values, want)
+		}
+	}
+	if _, ok := queue.Retract("camera", late); ok {
+		t.Error("Retract succeeded on a popped item.")
+	}
+}
+
+func TestPriorityQueueStableOrder(t *testing.T) {
+	pq := core.NewMinPriorityQueue[int, int](core.WithStableOrder())
+	for i := 0; i < 100; i++ {
+		pq.Push(i, i%3)
+	}
+	prev := map[int]int{0: -1, 1: -1, 2: -1}
+	for !pq.Empty() {
+		value, priority, _ := pq.Pop()
+		if value <= prev[priority] {
+			t.Fatalf("value %d with priority %d popped after %d", value, priority, prev[priority])
+		}
+		prev[priority] = value
+	}
+
+	// 次级比较：同优先级下偶数先出，其余按插入顺序
+	pq.SetTieBreaker(func(lhs, rhs int) bool {
+		return lhs%2 == 0 && rhs%2 != 0
+	})
+	for _, value := range []int{1, 3, 2, 5, 4} {
+		pq.Push(value, 0)
+	}
+	for _, want := range []int{2, 4, 1, 3, 5} {
+		if value, _, _ := pq.Pop(); value != want {
+			t.Errorf("Pop returned %d, want %d", value, want)
+		}
+	}
+}
+
+func TestTemporalQueueEqualTimestampsFIFO(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 30))
+	queue.CreateChannel("camera", core.WithOrdering(core.OrderingHeap))
+
+	queue.Push("camera", "later", 2)
+	for i := 0; i < 20; i++ {
+		queue.Push("camera", i, 1)
+	}
+	for i := 0; i < 20; i++ {
+		if values, _, _ := queue.Pop(); values["camera"] != i {
+			t.Fatalf("Pop returned %v, want %d", values["camera"], i)
+		}
+	}
+}
+
+func TestPriorityQueueFunc(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	times := core.NewPriorityQueueFunc[int](func(a, b time.Time) bool {
+		return a.Before(b)
+	})
+	for _, offset := range []int{3, 1, 4, 0, 2} {
+		times.Push(offset, base.Add(time.Duration(offset)*time.Second))
+	}
+	for want := 0; want < 5; want++ {
+		if value, _, _ := times.Pop(); value != want {
+			t.Errorf("Pop returned %d, want %d", value, want)
+		}
+	}
+
+	// 复合键：时间戳早者优先，时间戳相同时通道优先级高者优先
+	type key struct {
+		ts       int64
+		priority int
+	}
+	keys := core.NewPriorityQueueFunc[string](func(a, b key) bool {
+		if a.ts != b.ts {
+			return a.ts < b.ts
+		}
+		return a.priority > b.priority
+	})
+	keys.Push("audio", key{ts: 10, priority: 1})
+	keys.Push("video", key{ts: 10, priority: 5})
+	keys.Push("meta", key{ts: 5, priority: 0})
+	for _, want := range []string{"meta", "video", "audio"} {
+		if value, _, _ := keys.Pop(); value != want {
+			t.Errorf("Pop returned %s, want %s", value, want)
+		}
+	}
+}
+
+func TestPriorityQueueUtilities(t *testing.T) {
+	pq := core.NewMinPriorityQueue[int, int](core.WithStableOrder())
+	for i := 0; i < 50; i++ {
+		pq.Push(i, (i*37)%50)
+	}
+
+	snapshot := pq.Snapshot()
+	if len(snapshot) != 50 || pq.Size() != 50 {
+		t.Fatalf("Snapshot returned %d items, queue holds %d", len(snapshot), pq.Size())
+	}
+	for i, item := range snapshot {
+		if item.Priority != i {
+			t.Fatalf("Snapshot[%d] has priority %d", i, item.Priority)
+		}
+	}
+	peek := pq.PeekN(5)
+	if len(peek) != 5 || peek[4] != snapshot[4] {
+		t.Errorf("PeekN(5) = %v", peek)
+	}
+
+	// 遍历过程中弹出不影响迭代
+	next := 0
+	for _, priority := range pq.All() {
+		if priority != next {
+			t.Fatalf("All yielded priority %d, want %d", priority, next)
+		}
+		pq.Pop()
+		next++
+	}
+	if next != 50 || !pq.Empty() {
+		t.Fatalf("All yielded %d items, %d left in queue", next, pq.Size())
+	}
+
+	handles := make([]core.Handle, 20)
+	for i := range handles {
+		handles[i] = pq.Push(i, i)
+	}
+	if removed := pq.RemoveIf(func(value, _ int) bool { return value%2 == 1 }); removed != 10 {
+		t.Errorf("RemoveIf removed %d items, want 10", removed)
+	}
+	if pq.Contains(handles[3]) || !pq.Contains(handles[4]) {
+		t.Error("RemoveIf left stale handles")
+	}
+	if !pq.Update(handles[4], -1) {
+		t.Error("Update failed after RemoveIf")
+	}
+
+	other := core.NewMinPriorityQueue[int, int]()
+	other.Push(100, -2)
+	other.Push(101, 100)
+	pq.Merge(other)
+	if other.Size() != 2 || pq.Size() != 12 {
+		t.Errorf("Merge: got sizes %d and %d", other.Size(), pq.Size())
+	}
+	for _, want := range []int{100, 4, 0, 2} {
+		if value, _, _ := pq.Pop(); value != want {
+			t.Errorf("Pop returned %d, want %d", value, want)
+		}
+	}
+
+	pq.Clear()
+	if !pq.Empty() || pq.Contains(handles[6]) {
+		t.Error("Clear left items in the queue")
+	}
+	pq.Push(1, 1)
+	if value, _, ok := pq.Pop(); !ok || value != 1 {
+		t.Error("queue unusable after Clear")
+	}
+}
+
+func TestPriorityQueueCapacity(t *testing.T) {
+	// 保留最近的K帧：按时间戳最小堆，逐出队首（最旧的帧）
+	var dropped []int
+	recent := core.NewMinPriorityQueue[int, int64]()
+	recent.SetCapacity(3, core.EvictHead, func(value int, _ int64) {
+		dropped = append(dropped, value)
+	})
+	for i := 0; i < 6; i++ {
+		recent.Push(i, int64(i))
+	}
+	if h := recent.Push(-1, -1); recent.Contains(h) {
+		t.Error("a frame older than every kept frame should be evicted right away")
+	}
+	if want := []int{0, 1, 2, -1}; fmt.Sprint(dropped) != fmt.Sprint(want) {
+		t.Errorf("evicted %v, want %v", dropped, want)
+	}
+	for _, want := range []int{3, 4, 5} {
+		if value, _, _ := recent.Pop(); value != want {
+			t.Errorf("Pop returned %d, want %d", value, want)
+		}
+	}
+
+	// 得分最高的K个样本：最大堆，逐出队尾（得分最低的样本）
+	best := core.NewMaxPriorityQueue[string, float64]()
+	best.SetCapacity(2, core.EvictTail, nil)
+	for i, score := range []float64{0.3, 0.9, 0.1, 0.7, 0.5} {
+		best.Push(fmt.Sprint(i), score)
+	}
+	if snapshot := best.Snapshot(); len(snapshot) != 2 || snapshot[0].Value != "1" || snapshot[1].Value != "3" {
+		t.Errorf("kept %v, want samples 1 and 3", snapshot)
+	}
+
+	// 缩小容量时立即逐出超出的样本
+	evicted := 0
+	best.SetCapacity(1, core.EvictTail, func(string, float64) { evicted++ })
+	if evicted != 1 || best.Size() != 1 {
+		t.Errorf("shrinking evicted %d items, %d left", evicted, best.Size())
+	}
+}
+
+func TestPriorityQueueArity(t *testing.T) {
+	for _, arity := range []int{2, 3, 4, 8} {
+		rng := rand.New(rand.NewSource(int64(arity)))
+		pq := core.NewMinPriorityQueue[int, int](core.WithArity(arity))
+		handles := make(map[int]core.Handle)
+		priorities := make(map[int]int)
+		for i := 0; i < 2000; i++ {
+			priorities[i] = rng.Intn(500)
+			handles[i] = pq.Push(i, priorities[i])
+		}
+		for i := 0; i < 2000; i += 3 {
+			pq.Remove(handles[i])
+			delete(priorities, i)
+		}
+		for i := 1; i < 2000; i += 3 {
+			priorities[i] = rng.Intn(500)
+			pq.Update(handles[i], priorities[i])
+		}
+
+		want := make([]int, 0, len(priorities))
+		for _, priority := range priorities {
+			want = append(want, priority)
+		}
+		sort.Ints(want)
+		for i, priority := range want {
+			if _, got, ok := pq.Pop(); !ok || got != priority {
+				t.Fatalf("arity %d: Pop #%d returned priority %d, want %d", arity, i, got, priority)
+			}
+		}
+		if !pq.Empty() {
+			t.Errorf("arity %d: %d items left", arity, pq.Size())
+		}
+	}
+}
+
+// benchmarkPayload 模拟较大的任务数据
+type benchmarkPayload struct {
+	data [64]byte
+}
+
+// BenchmarkPriorityQueue 比较不同堆的叉数、任务数据大小以及推入/弹出比例下PriorityQueue的性能。
+// 队列预先填充10000个任务，每次操作按比例推入或弹出一个任务。
+func BenchmarkPriorityQueue(b *testing.B) {
+	mixes := []struct {
+		name   string
+		pushes int
+		pops   int
+	}{
+		{"push", 1, 0},
+		{"push3pop1", 3, 1},
+		{"push1pop1", 1, 1},
+		{"push1pop3", 1, 3},
+	}
+	for _, arity := range []int{2, 4, 8} {
+		for _, mix := range mixes {
+			b.Run(fmt.Sprintf("arity=%d/item=int/%s", arity, mix.name), func(b *testing.B) {
+				benchmarkPriorityQueue(b, arity, mix.pushes, mix.pops, 0)
+			})
+			b.Run(fmt.Sprintf("arity=%d/item=64B/%s", arity, mix.name), func(b *testing.B) {
+				benchmarkPriorityQueue(b, arity, mix.pushes, mix.pops, benchmarkPayload{})
+			})
+		}
+	}
+}
+
+func benchmarkPriorityQueue[T any](b *testing.B, arity, pushes, pops int, value T) {
+	const prefill = 10000
+	rng := rand.New(rand.NewSource(1))
+	pq := core.NewMinPriorityQueue[T, int64](core.WithArity(arity))
+	for i := 0; i < prefill; i++ {
+		pq.Push(value, rng.Int63())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%(pushes+pops) < pushes || pq.Empty() {
+			pq.Push(value, rng.Int63())
+		} else {
+			pq.Pop()
+		}
+	}
+}
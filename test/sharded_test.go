@@ -0,0 +1,146 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestShardedTemporalQueue(t *testing.T) {
+	queue := core.NewShardedTemporalQueue(core.PTSTimeline(1, 1000), 4)
+
+	wg := sync.WaitGroup{}
+	for c := 0; c < 50; c++ {
+		key := fmt.Sprintf("channel_%d", c)
+		queue.CreateChannel(key)
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := 199; i >= 0; i-- {
+				queue.Push(key, c, uint64(i*50+c%7))
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	// 内省结果汇总所有分片
+	if queue.Len() != 50*200 {
+		t.Errorf("Len = %d, want %d", queue.Len(), 50*200)
+	}
+	if n, ok := queue.ChannelLen("channel_3"); !ok || n != 200 {
+		t.Errorf("ChannelLen = %d, %v", n, ok)
+	}
+	if _, ok := queue.ChannelLen("missing"); ok {
+		t.Error("ChannelLen reported a missing channel")
+	}
+	infos := queue.Channels()
+	if len(infos) != 50 || infos[0].Key != "channel_0" || infos[1].Key != "channel_1" || infos[0].Len != 200 {
+		t.Errorf("Channels reports %d channels starting with %+v", len(infos), infos[0])
+	}
+
+	select {
+	case <-queue.Ready():
+	default:
+		t.Error("Ready should be signalled after Push")
+	}
+
+	// 时间戳相同的任务即使位于不同分片，也应合并为一帧
+	values, frame, ok := queue.Head()
+	if !ok || frame != 0 || len(values) != 8 {
+		t.Fatalf("Head returned %d items at frame %d", len(values), frame)
+	}
+	queue.Release(values)
+
+	count := 0
+	prev := uint64(0)
+	for !queue.Empty() {
+		values, frame, ok := queue.Pop()
+		if !ok {
+			t.Fatal("Pop operation failed.")
+		}
+		if frame < prev {
+			t.Fatalf("Pop returned frame %d after %d", frame, prev)
+		}
+		for key, value := range values {
+			if key != fmt.Sprintf("channel_%d", value) || uint64(value.(int)%7) != frame%50 {
+				t.Fatalf("frame %d carries %s=%v", frame, key, value)
+			}
+		}
+		prev = frame
+		count += len(values)
+		queue.Release(values)
+	}
+	if count != 50*200 {
+		t.Errorf("popped %d items, want %d", count, 50*200)
+	}
+}
+
+// BenchmarkShardedPush 比较多个生产者向不同通道并发推入时分片与不分片队列的性能
+func BenchmarkShardedPush(b *testing.B) {
+	const channels = 64
+	keys := make([]string, channels)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("channel_%d", i)
+	}
+	for _, shards := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			queue := core.NewShardedTemporalQueue(core.PTSTimeline(1, 1000), shards)
+			for _, key := range keys {
+				queue.CreateChannel(key)
+			}
+
+			producer := atomic.Int64{}
+			b.RunParallel(func(pb *testing.PB) {
+				key := keys[producer.Add(1)%channels]
+				frame := uint64(0)
+				for pb.Next() {
+					queue.Push(key, frame, frame)
+					frame++
+				}
+			})
+		})
+	}
+}
+
+func TestShardedConcurrentConsumers(t *testing.T) {
+	queue := core.NewShardedTemporalQueue(core.PTSTimeline(1, 1000), 4)
+	const channels, frames, consumers = 16, 2000, 4
+	for c := 0; c < channels; c++ {
+		key := fmt.Sprintf("channel_%d", c)
+		queue.CreateChannel(key)
+		for i := 0; i < frames; i++ {
+			queue.Push(key, c, uint64(i))
+		}
+	}
+
+	// 多个消费者并发Pop：每一帧都应包含所有通道的任务，队列非空时Pop不应返回false
+	var popped atomic.Int64
+	wg := sync.WaitGroup{}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				values, frame, ok := queue.Pop()
+				if !ok {
+					if !queue.Empty() {
+						t.Error("Pop returned false while the queue is not empty")
+					}
+					return
+				}
+				if len(values) != channels {
+					t.Errorf("frame %d was split: %d of %d channels", frame, len(values), channels)
+				}
+				popped.Add(int64(len(values)))
+				queue.Release(values)
+			}
+		}()
+	}
+	wg.Wait()
+	if popped.Load() != channels*frames {
+		t.Errorf("popped %d items, want %d", popped.Load(), channels*frames)
+	}
+}
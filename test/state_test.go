@@ -0,0 +1,444 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestChannelLifecycle(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	var transitions []string
+	queue.OnChannelState(func(key string, from, to core.ChannelState) {
+		transitions = append(transitions, fmt.Sprintf("%s:%v->%v", key, from, to))
+	})
+
+	queue.CreateChannel("camera")
+	queue.Push("camera", "a", 1)
+	queue.Push("camera", "b", 2)
+	queue.CloseChannel("camera")
+	if state, _ := queue.ChannelState("camera"); state != core.ChannelClosing {
+		t.Fatalf("state after CloseChannel = %v, want Closing", state)
+	}
+
+	// 关闭后的推入被拒绝，已有的任务照常出队
+	queue.Push("camera", "c", 0)
+	for _, want := range []string{"a", "b"} {
+		if values, _, _ := queue.Pop(); values["camera"] != want {
+			t.Fatalf("Pop returned %v, want %s", values["camera"], want)
+		}
+	}
+	if _, ok := queue.ChannelState("camera"); ok {
+		t.Fatal("drained channel should be removed")
+	}
+	want := "[camera:Open->Closing camera:Closing->Drained camera:Drained->Removed]"
+	if fmt.Sprint(transitions) != want {
+		t.Errorf("transitions = %v, want %s", transitions, want)
+	}
+
+	// 移除后可以重新创建同名通道
+	queue.CreateChannel("camera")
+	queue.Push("camera", "d", 3)
+	if values, _, _ := queue.Pop(); values["camera"] != "d" {
+		t.Errorf("Pop returned %v from the recreated channel", values["camera"])
+	}
+}
+
+func TestSamplerLifecycle(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue()
+	queue.CreateChannel("channel1")
+	var mu sync.Mutex
+	var transitions []string
+	queue.OnSamplerState(func(from, to core.SamplerState) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, fmt.Sprintf("%v->%v", from, to))
+	})
+
+	weights := &sync.Map{}
+	weights.Store("channel1", 1.0)
+	for i := 0; i < 3; i++ {
+		queue.StartSample(30, weights)
+		if queue.SamplerState() != core.SamplerSampling {
+			t.Fatalf("state after StartSample = %v", queue.SamplerState())
+		}
+		queue.CloseSample()
+		if queue.SamplerState() != core.SamplerRaw {
+			t.Fatalf("state after CloseSample = %v", queue.SamplerState())
+		}
+	}
+	if len(transitions) != 9 || transitions[1] != "Sampling->Stopping" {
+		t.Errorf("transitions = %v", transitions)
+	}
+
+	// 并发地启停采样与推入不应产生数据竞争
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				queue.StartSample(30+j, weights)
+				queue.CloseSample()
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				queue.Push("channel1", j, int64(i*100+j))
+				queue.Pop()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue()
+	events, cancel := queue.Subscribe()
+	var kinds []core.LifecycleEventKind
+	queue.OnLifecycleEvent(func(event core.LifecycleEvent) {
+		kinds = append(kinds, event.Kind)
+	})
+
+	queue.CreateChannel("camera")
+	queue.CreateChannel("camera")
+	queue.Push("camera", "a", 1)
+	queue.Push("camera", "b", 2)
+	queue.CloseChannel("camera")
+	queue.Drain()
+	queue.StartSample(30, &sync.Map{})
+	queue.CloseSample()
+
+	want := []core.LifecycleEvent{
+		{Kind: core.EventChannelCreated, Key: "camera"},
+		{Kind: core.EventChannelFirstPush, Key: "camera"},
+		{Kind: core.EventChannelClosing, Key: "camera"},
+		{Kind: core.EventChannelDrained, Key: "camera"},
+		{Kind: core.EventChannelRemoved, Key: "camera"},
+		{Kind: core.EventSamplerStarted},
+		{Kind: core.EventSamplerStopped},
+	}
+	for _, w := range want {
+		if got := <-events; got != w {
+			t.Errorf("received %v %q, want %v %q", got.Kind, got.Key, w.Kind, w.Key)
+		}
+	}
+	if len(kinds) != len(want) {
+		t.Errorf("callback received %v", kinds)
+	}
+
+	cancel()
+	queue.CreateChannel("other")
+	if _, ok := <-events; ok {
+		t.Error("events channel should be closed after cancel")
+	}
+}
+
+func TestCreatedBeforeFirstPush(t *testing.T) {
+	// 多个生产者同时向自动创建的通道推入任务：任何一个生产者都只能在EventChannelCreated发出之后取得通道，
+	// 因此每个键的第一个事件总是EventChannelCreated。
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000), core.WithAutoCreate())
+	var mu sync.Mutex
+	first := make(map[string]core.LifecycleEventKind)
+	queue.OnLifecycleEvent(func(e core.LifecycleEvent) {
+		mu.Lock()
+		if _, ok := first[e.Key]; !ok {
+			first[e.Key] = e.Kind
+		}
+		mu.Unlock()
+	})
+
+	const keys, producers = 200, 4
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				queue.Push(fmt.Sprintf("cam%d", k), k, uint64(k))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(first) != keys {
+		t.Fatalf("events for %d keys, want %d", len(first), keys)
+	}
+	for key, kind := range first {
+		if kind != core.EventChannelCreated {
+			t.Errorf("first event of %s is %v, want ChannelCreated", key, kind)
+		}
+	}
+}
+
+func TestPauseAndResumeChannel(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	for _, key := range []string{"cam0", "cam1"} {
+		queue.CreateChannel(key)
+	}
+	push := func(key string, frames ...uint64) {
+		for _, frame := range frames {
+			queue.Push(key, frame, frame)
+		}
+	}
+	var evicted []string
+	queue.OnEvict(func(key string, value any, frame uint64) {
+		evicted = append(evicted, fmt.Sprintf("%s@%d", key, frame))
+	})
+
+	push("cam0", 1, 2, 3)
+	push("cam1", 1, 5)
+	if !queue.PauseChannel("cam1") || queue.PauseChannel("cam1") {
+		t.Fatal("PauseChannel should succeed only once")
+	}
+
+	// 暂停的通道照常接收任务，但不参与时间合并
+	push("cam1", 2)
+	for _, want := range []uint64{1, 2, 3} {
+		values, frame, _ := queue.Pop()
+		if frame != want || len(values) != 1 || values["cam0"] == nil {
+			t.Fatalf("Pop returned %v at %d, want cam0 at %d", values, frame, want)
+		}
+	}
+	if !queue.Empty() || queue.Len() != 3 {
+		t.Fatalf("Empty = %v, Len = %d while cam1 is paused", queue.Empty(), queue.Len())
+	}
+	if infos := queue.Channels(); !infos[1].Paused {
+		t.Errorf("Channels reports %+v", infos[1])
+	}
+
+	// 丢弃早于当前输出时刻（3）的积压任务
+	if dropped, ok := queue.ResumeChannel("cam1", core.ResumeDropStale); !ok || dropped != 2 {
+		t.Fatalf("ResumeChannel dropped %d, %v", dropped, ok)
+	}
+	// 丢弃的任务按逐出上报并计数
+	if fmt.Sprint(evicted) != "[cam1@1 cam1@2]" || queue.ChannelEvicted("cam1") != 2 || queue.Evicted() != 2 {
+		t.Errorf("evicted %v, ChannelEvicted = %d, Evicted = %d", evicted, queue.ChannelEvicted("cam1"), queue.Evicted())
+	}
+	if values, frame, _ := queue.Pop(); frame != 5 || values["cam1"] != uint64(5) {
+		t.Errorf("Pop returned %v at %d after resume", values, frame)
+	}
+	if _, ok := queue.ResumeChannel("cam1", core.ResumeDeliver); ok {
+		t.Error("ResumeChannel should fail on a running channel")
+	}
+
+	// 其余策略：保留或全部丢弃积压任务
+	queue.PauseChannel("cam0")
+	push("cam0", 7, 8)
+	if dropped, _ := queue.ResumeChannel("cam0", core.ResumeDeliver); dropped != 0 || queue.Len() != 2 {
+		t.Errorf("ResumeDeliver dropped %d, Len = %d", dropped, queue.Len())
+	}
+	queue.PauseChannel("cam0")
+	if dropped, _ := queue.ResumeChannel("cam0", core.ResumeDrop); dropped != 2 || !queue.Empty() {
+		t.Errorf("ResumeDrop dropped %d", dropped)
+	}
+	if len(evicted) != 4 || queue.ChannelEvicted("cam0") != 2 || queue.Evicted() != 4 {
+		t.Errorf("evicted %v, ChannelEvicted = %d, Evicted = %d", evicted, queue.ChannelEvicted("cam0"), queue.Evicted())
+	}
+
+	// 已关闭的通道在暂停期间不会出队，恢复后清空才被移除
+	push("cam0", 10)
+	queue.PauseChannel("cam0")
+	queue.CloseChannel("cam0")
+	if state, _ := queue.ChannelState("cam0"); state != core.ChannelClosing {
+		t.Fatalf("state = %v, want Closing", state)
+	}
+	queue.ResumeChannel("cam0", core.ResumeDeliver)
+	queue.Drain()
+	if _, ok := queue.ChannelState("cam0"); ok {
+		t.Error("cam0 should be removed once drained")
+	}
+}
+
+func TestReopenChannel(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	var events []core.LifecycleEvent
+	queue.OnLifecycleEvent(func(e core.LifecycleEvent) {
+		if e.Key == "cam0" {
+			events = append(events, e)
+		}
+	})
+	queue.CreateChannel("cam0")
+	queue.CreateChannel("cam1")
+	queue.Push("cam0", "old3", 3)
+	queue.Push("cam0", "old5", 5)
+	h, _ := queue.PushHandle("cam0", "old9", 9)
+	queue.Push("cam1", "x4", 4)
+
+	// 关闭后在清空前重新创建：新一代通道立即接收任务
+	queue.CloseChannel("cam0")
+	queue.CreateChannel("cam0")
+	queue.Push("cam0", "new1", 1)
+	queue.Push("cam0", "new6", 6)
+	if state, _ := queue.ChannelState("cam0"); state != core.ChannelOpen {
+		t.Fatalf("state = %v, want Open", state)
+	}
+	if n, _ := queue.ChannelLen("cam0"); n != 5 || queue.Len() != 6 {
+		t.Fatalf("ChannelLen = %d, Len = %d", n, queue.Len())
+	}
+	if infos := queue.Channels(); len(infos) != 3 || infos[0].Generation != 0 || infos[1].Generation != 1 {
+		t.Fatalf("Channels reports %+v", infos)
+	}
+
+	// 旧通道发放的句柄仍然有效
+	if !queue.Retime("cam0", h, 7) {
+		t.Fatal("Retime failed on the draining generation")
+	}
+
+	// 旧一代的任务全部先于新一代输出，即使新一代的时间戳更早
+	var got []any
+	for _, frame := range queue.PopN(1) {
+		got = append(got, frame.Values["cam0"])
+	}
+	values, _, _ := queue.PopFrom("cam0")
+	got = append(got, values["cam0"])
+	for _, frame := range queue.Drain() {
+		if v, ok := frame.Values["cam0"]; ok {
+			got = append(got, v)
+		}
+	}
+	if fmt.Sprint(got) != "[old3 old5 old9 new1 new6]" {
+		t.Errorf("cam0 delivered %v", got)
+	}
+	if infos := queue.Channels(); len(infos) != 2 || infos[0].Generation != 1 {
+		t.Errorf("Channels reports %+v after draining", infos)
+	}
+
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, fmt.Sprintf("%v/%d", e.Kind, e.Generation))
+	}
+	want := "[ChannelCreated/0 ChannelFirstPush/0 ChannelClosing/0 ChannelCreated/1 ChannelFirstPush/1 ChannelDrained/0 ChannelRemoved/0]"
+	if fmt.Sprint(kinds) != want {
+		t.Errorf("events = %v, want %v", kinds, want)
+	}
+
+	// 打开的通道不会被重新创建
+	queue.CreateChannel("cam0")
+	if infos := queue.Channels(); infos[0].Generation != 1 || infos[0].Len != 0 {
+		t.Errorf("CreateChannel replaced an open channel: %+v", infos[0])
+	}
+}
+
+func TestCloseGatedChannel(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	var removed []uint64
+	queue.OnLifecycleEvent(func(e core.LifecycleEvent) {
+		if e.Kind == core.EventChannelRemoved {
+			removed = append(removed, e.Generation)
+		}
+	})
+	queue.CreateChannel("cam0")
+	queue.Push("cam0", "old1", 1)
+	queue.Push("cam0", "old2", 2)
+
+	// 第1代在第0代清空前被关闭且为空：它不能先于第0代被移除，否则再次创建的通道不再链接到第0代
+	queue.CloseChannel("cam0")
+	queue.CreateChannel("cam0")
+	queue.CloseChannel("cam0")
+	queue.CreateChannel("cam0")
+	queue.Push("cam0", "new0", 0)
+
+	infos := queue.Channels()
+	if len(infos) != 3 || infos[0].Generation != 0 || infos[1].Generation != 1 || infos[2].Generation != 2 {
+		t.Fatalf("Channels reports %+v", infos)
+	}
+	if n, _ := queue.ChannelLen("cam0"); n != 3 {
+		t.Fatalf("ChannelLen = %d, want 3", n)
+	}
+
+	// 各代依次输出，同一帧中不会出现同一个键的两个任务
+	values, _, _ := queue.Pop()
+	got := []any{values["cam0"]}
+	for _, frame := range queue.Drain() {
+		if len(frame.Values) != 1 {
+			t.Errorf("frame %d merged %v", frame.NTP, frame.Values)
+		}
+		got = append(got, frame.Values["cam0"])
+	}
+	if fmt.Sprint(got) != "[old1 old2 new0]" {
+		t.Errorf("cam0 delivered %v", got)
+	}
+	if infos := queue.Channels(); len(infos) != 1 || infos[0].Generation != 2 {
+		t.Errorf("Channels reports %+v after draining", infos)
+	}
+	if fmt.Sprint(removed) != "[0 1]" {
+		t.Errorf("removed generations %v, want [0 1]", removed)
+	}
+}
+
+func TestConcurrentResumeChannel(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+		queue.CreateChannel("cam0")
+		queue.PauseChannel("cam0")
+		for frame := uint64(0); frame < 100; frame++ {
+			queue.Push("cam0", frame, frame)
+		}
+
+		// 并发恢复时只有一个调用成功，积压的任务只被它丢弃一次
+		var mu sync.Mutex
+		var resumed, dropped int
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n, ok := queue.ResumeChannel("cam0", core.ResumeDrop)
+				mu.Lock()
+				defer mu.Unlock()
+				if ok {
+					resumed++
+				} else if n != 0 {
+					t.Errorf("failed ResumeChannel dropped %d items", n)
+				}
+				dropped += n
+			}()
+		}
+		wg.Wait()
+		if resumed != 1 || dropped != 100 {
+			t.Fatalf("%d resumes succeeded, %d items dropped", resumed, dropped)
+		}
+	}
+}
+
+func TestReopenPausedChannel(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	queue.CreateChannel("k")
+	queue.Push("k", "gen0", 1)
+	queue.PauseChannel("k")
+
+	// 暂停期间关闭并重新创建两次：新一代通道继承暂停状态，Drain不会输出任何一代，也不会出错
+	queue.CloseChannel("k")
+	queue.CreateChannel("k")
+	queue.Push("k", "gen1", 2)
+	queue.CloseChannel("k")
+	queue.CreateChannel("k")
+	queue.Push("k", "gen2", 3)
+	if frames := queue.Drain(); len(frames) != 0 {
+		t.Fatalf("Drain returned %d frames while k is paused", len(frames))
+	}
+	for _, info := range queue.Channels() {
+		if !info.Paused {
+			t.Errorf("generation %d is not paused", info.Generation)
+		}
+	}
+
+	// 恢复作用于所有通道代，各代的任务按代的先后输出
+	if _, ok := queue.ResumeChannel("k", core.ResumeDeliver); !ok {
+		t.Fatal("ResumeChannel failed on a recreated paused channel")
+	}
+	var got []any
+	for _, frame := range queue.Drain() {
+		got = append(got, frame.Values["k"])
+	}
+	if fmt.Sprint(got) != "[gen0 gen1 gen2]" || queue.Len() != 0 {
+		t.Errorf("Drain delivered %v, Len = %d", got, queue.Len())
+	}
+	if infos := queue.Channels(); len(infos) != 1 || infos[0].Generation != 2 {
+		t.Errorf("Channels reports %+v", infos)
+	}
+}
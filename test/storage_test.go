@@ -0,0 +1,78 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestSkipListStorage(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	queue.CreateChannel("hot", core.WithStorage(core.StorageSkipList))
+	if _, ok := queue.PushHandle("hot", 0, 0); ok {
+		t.Error("PushHandle should fail on a skiplist channel")
+	}
+
+	// 1000个生产者并发推入同一通道，每个生产者的时间戳乱序且与其他生产者重复
+	const producers, perProducer = 1000, 20
+	wg := sync.WaitGroup{}
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := perProducer - 1; i >= 0; i-- {
+				queue.Push("hot", p, uint64(i*7%perProducer))
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	count := 0
+	prev := uint64(0)
+	for !queue.Empty() {
+		_, frame, ok := queue.Pop()
+		if !ok || frame < prev {
+			t.Fatalf("Pop returned frame %d after %d", frame, prev)
+		}
+		prev = frame
+		count++
+	}
+	if count != producers*perProducer {
+		t.Errorf("popped %d items, want %d", count, producers*perProducer)
+	}
+
+	// 时间戳相同的任务按推入顺序出队
+	for i := 0; i < 100; i++ {
+		queue.Push("hot", i, 1)
+	}
+	for i := 0; i < 100; i++ {
+		if values, _, _ := queue.Pop(); values["hot"] != i {
+			t.Fatalf("Pop returned %v, want %d", values["hot"], i)
+		}
+	}
+}
+
+// BenchmarkHotChannelPush 比较多个生产者并发推入同一通道时各存储的性能
+func BenchmarkHotChannelPush(b *testing.B) {
+	storages := map[string]core.ChannelStorage{
+		"hybrid":   core.StorageHybrid,
+		"skiplist": core.StorageSkipList,
+	}
+	for _, name := range []string{"hybrid", "skiplist"} {
+		b.Run(fmt.Sprintf("storage=%s", name), func(b *testing.B) {
+			queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+			queue.CreateChannel("hot", core.WithStorage(storages[name]))
+
+			frame := atomic.Uint64{}
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ntp := frame.Add(1)
+					queue.Push("hot", ntp, ntp)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+// newSubsetQueue 创建包含音频、视频与元数据三个通道的队列
+func newSubsetQueue() *core.TemporalQueue[uint64] {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 1000))
+	for _, key := range []string{"audio", "video", "meta"} {
+		queue.CreateChannel(key)
+	}
+	queue.Push("video", "v1", 1)
+	queue.Push("audio", "a1", 2)
+	queue.Push("audio", "a2", 3)
+	queue.Push("meta", "m3", 3)
+	queue.Push("video", "v3", 3)
+	return queue
+}
+
+func TestHeadOf(t *testing.T) {
+	queue := newSubsetQueue()
+	if value, frame, ok := queue.HeadOf("audio"); !ok || value != "a1" || frame != 2 {
+		t.Errorf("HeadOf(audio) = %v, %d, %v", value, frame, ok)
+	}
+	// HeadOf不弹出任务
+	if n, _ := queue.ChannelLen("audio"); n != 2 {
+		t.Errorf("ChannelLen(audio) = %d after HeadOf, want 2", n)
+	}
+	if _, _, ok := queue.HeadOf("missing"); ok {
+		t.Error("HeadOf should fail on a missing channel")
+	}
+
+	queue.CreateChannel("empty")
+	if _, _, ok := queue.HeadOf("empty"); ok {
+		t.Error("HeadOf should fail on an empty channel")
+	}
+}
+
+func TestPopFrom(t *testing.T) {
+	queue := newSubsetQueue()
+
+	// 音频先于视频取出，不影响其他通道
+	values, frame, ok := queue.PopFrom("audio")
+	if !ok || frame != 2 || len(values) != 1 || values["audio"] != "a1" {
+		t.Errorf("PopFrom(audio) = %v, %d, %v", values, frame, ok)
+	}
+	queue.Release(values)
+
+	// 在子集内按时间合并：重复的键只计一次，不存在的键被忽略
+	values, frame, ok = queue.PopFrom("audio", "meta", "audio", "missing")
+	if !ok || frame != 3 || len(values) != 2 || values["audio"] != "a2" || values["meta"] != "m3" {
+		t.Errorf("PopFrom(audio, meta) = %v, %d, %v", values, frame, ok)
+	}
+	if _, _, ok := queue.PopFrom("audio", "meta"); ok {
+		t.Error("PopFrom should fail once the channels are empty")
+	}
+}
+
+func TestPopFromLeavesOtherChannels(t *testing.T) {
+	queue := newSubsetQueue()
+	queue.PopFrom("audio")
+	queue.PopFrom("audio", "meta")
+
+	// 全局合并仍然照常进行，只剩下视频通道的任务
+	for _, want := range []string{"v1", "v3"} {
+		if values, _, _ := queue.Pop(); len(values) != 1 || values["video"] != want {
+			t.Errorf("Pop returned %v, want %s", values, want)
+		}
+	}
+	if !queue.Empty() {
+		t.Error("queue should be empty")
+	}
+}
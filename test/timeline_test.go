@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestTemporalQueueWithPTSTimeline(t *testing.T) {
+	queue := core.NewTemporalQueue(core.PTSTimeline(1, 25))
+	queue.CreateChannel("video")
+	queue.CreateChannel("depth")
+
+	queue.Push("video", "v2", 2)
+	queue.Push("video", "v0", 0)
+	queue.Push("depth", "d0", 0)
+	queue.Push("depth", "d1", 1)
+
+	want := []struct {
+		frame uint64
+		count int
+	}{{0, 2}, {1, 1}, {2, 1}}
+	for _, w := range want {
+		values, frame, ok := queue.Pop()
+		if !ok {
+			t.Fatal("Pop operation failed.")
+		}
+		if frame != w.frame || len(values) != w.count {
+			t.Errorf("Pop returned frame %d with %d values, want frame %d with %d values", frame, len(values), w.frame, w.count)
+		}
+	}
+	if !queue.Empty() {
+		t.Error("queue should be empty")
+	}
+
+	if d := core.PTSTimeline(1001, 30000).Distance(0, 30); d != 1001*time.Millisecond {
+		t.Errorf("Distance = %v", d)
+	}
+}
+
+func TestTemporalQueueWithTimeTimeline(t *testing.T) {
+	queue := core.NewTemporalQueue(core.TimeTimeline())
+	queue.CreateChannel("channel1")
+
+	now := time.Now()
+	queue.Push("channel1", "late", now.Add(-time.Second))
+	queue.Push("channel1", "early", now.Add(-2*time.Second))
+	queue.Push("channel1", "future", now.Add(time.Hour))
+
+	values, ts, ok := queue.Pop()
+	if !ok || values["channel1"] != "early" || !ts.Equal(now.Add(-2*time.Second)) {
+		t.Errorf("Pop returned %v at %v", values, ts)
+	}
+	values, _, ok = queue.Pop()
+	if !ok || values["channel1"] != "late" {
+		t.Errorf("Pop returned %v", values)
+	}
+	if _, _, ok = queue.Pop(); ok {
+		t.Error("Pop should not release items stamped in the future.")
+	}
+}
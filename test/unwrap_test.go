@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/murInJ/Asynchronous-Temporal-Queue/core"
+)
+
+func TestTimestampUnwrapper(t *testing.T) {
+	u := core.NewTimestampUnwrapper(32, 90000)
+	u.SetBase(0)
+
+	var events []core.TimestampEvent
+	u.OnEvent(func(event core.TimestampEvent) {
+		events = append(events, event)
+	})
+
+	// 3000个tick对应90kHz下的33.333ms
+	raws := []uint64{0xFFFFF000, 0xFFFFFBB8, 0x00000770, 0x00000388, 0x00001328}
+	want := []int64{0, 33333333, 66666666, 55555555, 100000000}
+	for i, raw := range raws {
+		if got := u.Unwrap(raw); got != want[i] {
+			t.Errorf("Unwrap(%#x) = %d, want %d", raw, got, want[i])
+		}
+	}
+	if len(events) != 1 || events[0].Kind != core.TimestampWrap {
+		t.Fatalf("expected a single wrap event, got %+v", events)
+	}
+
+	events = nil
+	got := u.Unwrap(0x00001328 + 90000*10)
+	if got != 10100000000 {
+		t.Errorf("Unwrap after jump = %d", got)
+	}
+	if len(events) != 1 || events[0].Kind != core.TimestampJump || events[0].Delta != 10*time.Second {
+		t.Errorf("expected a single jump event, got %+v", events)
+	}
+}
+
+func TestPushRaw(t *testing.T) {
+	queue := core.NewAsynchronousTemporalQueue()
+	queue.CreateChannel("rtp")
+
+	u := core.NewTimestampUnwrapper(32, 1000)
+	u.SetBase(time.Now().Add(-time.Minute).UnixNano())
+	if !queue.SetUnwrapper("rtp", u) {
+		t.Fatal("SetUnwrapper failed on an existing channel.")
+	}
+
+	queue.PushRaw("rtp", "b", 0x00000010)
+	queue.PushRaw("rtp", "a", 0xFFFFFFF0)
+
+	values, _, ok := queue.Pop()
+	if !ok || values["rtp"] != "a" {
+		t.Errorf("expected the pre-wrap sample first, got %v", values)
+	}
+	values, _, ok = queue.Pop()
+	if !ok || values["rtp"] != "b" {
+		t.Errorf("expected the post-wrap sample second, got %v", values)
+	}
+}